@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"catalyst/internal/config"
+	"catalyst/internal/daemon"
+	"catalyst/internal/db"
+	"catalyst/internal/headless"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/ssh"
+	"catalyst/internal/types"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: catalyst [flags] [command]\n\n")
+	fmt.Fprintf(out, "Without a command, Catalyst opens the spellbook of the current directory.\n\n")
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  run       Run runes of the current directory's spellbook\n")
+	fmt.Fprintf(out, "  runes     List the runes of the current directory's spellbook\n")
+	fmt.Fprintf(out, "  loegs     List the loegs of the current directory's spellbook\n")
+	fmt.Fprintf(out, "  history   List recorded executions\n")
+	fmt.Fprintf(out, "  show      Show a rune of the current directory's spellbook\n")
+	fmt.Fprintf(out, "  rune      Add, edit or remove a rune (rune add, rune edit, rune rm)\n")
+	fmt.Fprintf(out, "  loeg      Set or remove a loeg (loeg set, loeg rm)\n")
+	fmt.Fprintf(out, "  pick      Pick a rune and print its commands\n")
+	fmt.Fprintf(out, "  init      Print the picker key binding for zsh, bash or fish\n")
+	fmt.Fprintf(out, "  daemon    Run the scheduled runes of the current directory's spellbook\n\n")
+	fmt.Fprintf(out, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+// loadSpellbook fetches the spellbook of the current directory and returns
+// it with the directory.
+func loadSpellbook(cfg *config.Config) (*spellbook.Spellbook, string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	sb, err := spellbook.Fetch(ssh.NewClient(cfg.RuneCraftHost), dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not load the spellbook of %s: %w", dir, err)
+	}
+	return sb, dir, nil
+}
+
+// runCommand runs the command named by args[0] and returns the exit code.
+func runCommand(cfg *config.Config, database *db.Database, args []string) int {
+	switch args[0] {
+	case "run":
+		return runRunesCommand(cfg, database, args[1:])
+	case "runes":
+		return runesCommand(cfg, args[1:])
+	case "loegs":
+		return loegsCommand(cfg, args[1:])
+	case "history":
+		return historyCommand(cfg, database, args[1:])
+	case "show":
+		return showCommand(cfg, args[1:])
+	case "rune":
+		return runeCommand(cfg, args[1:])
+	case "loeg":
+		return loegCommand(cfg, args[1:])
+	case "pick":
+		return pickCommand(cfg, args[1:])
+	case "init":
+		return initCommand(args[1:])
+	case "daemon":
+		return daemonCommand(cfg, database, args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "catalyst: unknown command %q\n\n", args[0])
+	usage()
+	return 2
+}
+
+// daemonCommand fires scheduled runes until interrupted.
+func daemonCommand(cfg *config.Config, database *db.Database, args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: catalyst daemon\n\n")
+		fmt.Fprintf(fs.Output(), "Runs the scheduled runes of the current directory's spellbook until interrupted.\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: %v\n", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := daemon.New(cfg, database, dir, os.Stderr).Run(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// paramFlags collects NAME=VALUE parameter values.
+type paramFlags map[string]string
+
+func (p paramFlags) String() string {
+	pairs := make([]string, 0, len(p))
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (p paramFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%q is not NAME=VALUE", s)
+	}
+	p[name] = value
+	return nil
+}
+
+// runRunesCommand runs runes of the spellbook of the current directory, with
+// their output on stdout and the Catalyst log on stderr. Unless stdout is a
+// terminal, the commands run without one and their errors go to stderr. It
+// exits with the exit code of the command that failed, or 128 plus the
+// signal that killed it.
+func runRunesCommand(cfg *config.Config, database *db.Database, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	params := make(paramFlags)
+	fs.Var(params, "p", "Set a rune parameter, as `NAME=VALUE` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: catalyst run [-p NAME=VALUE]... RUNE...\n\n")
+		fmt.Fprintf(fs.Output(), "Runs runes of the current directory's spellbook, preceded by the runes they need.\n")
+		fmt.Fprintf(fs.Output(), "Parameters without a value use their default.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	sb, dir, err := loadSpellbook(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: %v\n", err)
+		return 1
+	}
+	var runes []types.Rune
+	for _, name := range fs.Args() {
+		r, ok := sb.Rune(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "catalyst: no rune named %q in spellbook %s\n", name, sb.Name)
+			return 1
+		}
+		runes = append(runes, r)
+	}
+
+	executor := headless.NewExecutor(cfg, database, sb, dir)
+	executor.Output = os.Stdout
+	if !isTerminal(os.Stdout) {
+		executor.Errors = os.Stderr
+	}
+	executor.Logs = os.Stderr
+	if dataDir, err := db.DataDir(); err == nil {
+		executor.DataDir = dataDir
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	result, err := executor.Run(ctx, runes, params, db.TriggerManual)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: %v\n", err)
+		return 1
+	}
+	switch {
+	case result.Status == db.StatusSucceeded:
+		return 0
+	case result.Status == db.StatusCanceled:
+		return 130
+	case result.Signal != nil:
+		if sig, ok := result.Signal.(syscall.Signal); ok {
+			return 128 + int(sig)
+		}
+	case result.ExitCode > 0:
+		return result.ExitCode
+	}
+	return 1
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
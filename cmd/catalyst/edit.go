@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"catalyst/internal/config"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/ssh"
+	"catalyst/internal/types"
+
+	"github.com/BurntSushi/toml"
+)
+
+// runeCommand creates, edits and deletes runes of the spellbook.
+func runeCommand(cfg *config.Config, args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  catalyst rune add --name NAME --desc DESCRIPTION -- COMMAND...\n")
+		fmt.Fprintf(os.Stderr, "  catalyst rune edit NAME\n")
+		fmt.Fprintf(os.Stderr, "  catalyst rune rm NAME\n")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "add":
+		return runeAddCommand(cfg, args[1:])
+	case "edit":
+		return runeEditCommand(cfg, args[1:])
+	case "rm":
+		return runeRmCommand(cfg, args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "catalyst: unknown rune command %q\n\n", args[0])
+	usage()
+	return 2
+}
+
+func runeAddCommand(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("rune add", flag.ContinueOnError)
+	name := fs.String("name", "", "Name of the rune")
+	desc := fs.String("desc", "", "Description of the rune")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: catalyst rune add --name NAME --desc DESCRIPTION -- COMMAND...\n\n")
+		fmt.Fprintf(fs.Output(), "Adds a rune running each COMMAND in turn to the current directory's spellbook.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return printed(err)
+	}
+	r := types.Rune{Name: *name, Description: *desc, Commands: types.Commands(fs.Args())}
+	if err := spellbook.CreateRune(ssh.NewClient(cfg.RuneCraftHost), dir, r); err != nil {
+		return printed(err)
+	}
+	fmt.Printf("Created rune %s\n", *name)
+	return 0
+}
+
+func runeRmCommand(cfg *config.Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: catalyst rune rm NAME\n")
+		return 2
+	}
+	sb, dir, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	if _, ok := sb.Rune(args[0]); !ok {
+		return printed(fmt.Errorf("no rune named %q in spellbook %s", args[0], sb.Name))
+	}
+	if err := spellbook.DeleteRune(ssh.NewClient(cfg.RuneCraftHost), dir, args[0]); err != nil {
+		return printed(err)
+	}
+	fmt.Printf("Deleted rune %s\n", args[0])
+	return 0
+}
+
+// editedRune is a rune as edited in TOML. Commands with a failure policy
+// of their own are inline tables, and durations are strings such as "2s".
+type editedRune struct {
+	Name           string            `toml:"name"`
+	Description    string            `toml:"description"`
+	Commands       []editedCommand   `toml:"commands"`
+	Needs          []string          `toml:"needs,omitempty"`
+	Interpreter    string            `toml:"interpreter,omitempty"`
+	Workdir        string            `toml:"workdir,omitempty"`
+	Target         string            `toml:"target,omitempty"`
+	Timeout        editedDuration    `toml:"timeout,omitzero"`
+	AllowFailure   bool              `toml:"allow_failure,omitempty"`
+	Retries        int               `toml:"retries,omitzero"`
+	Backoff        editedDuration    `toml:"backoff,omitzero"`
+	CommandTimeout editedDuration    `toml:"command_timeout,omitzero"`
+	Schedule       string            `toml:"schedule,omitempty"`
+	Watch          []string          `toml:"watch,omitempty"`
+	Parameters     []editedParameter `toml:"parameters,omitempty"`
+}
+
+type editedParameter struct {
+	Name        string   `toml:"name"`
+	Description string   `toml:"description,omitempty"`
+	Default     string   `toml:"default,omitempty"`
+	Choices     []string `toml:"choices,omitempty"`
+}
+
+func newEditedRune(r types.Rune) editedRune {
+	e := editedRune{
+		Name:           r.Name,
+		Description:    r.Description,
+		Commands:       make([]editedCommand, len(r.Commands)),
+		Needs:          r.Needs,
+		Interpreter:    r.Interpreter,
+		Workdir:        r.Workdir,
+		Target:         r.Target,
+		Timeout:        editedDuration(r.Timeout),
+		AllowFailure:   r.AllowFailure,
+		Retries:        r.Retries,
+		Backoff:        editedDuration(r.Backoff),
+		CommandTimeout: editedDuration(r.CommandTimeout),
+		Schedule:       r.Schedule,
+		Watch:          r.Watch,
+	}
+	for i, c := range r.Commands {
+		e.Commands[i] = editedCommand(c)
+	}
+	for _, p := range r.Parameters {
+		e.Parameters = append(e.Parameters, editedParameter(p))
+	}
+	return e
+}
+
+// rune returns the edited rune.
+func (e editedRune) rune() types.Rune {
+	r := types.Rune{
+		Name:        e.Name,
+		Description: e.Description,
+		Commands:    make([]types.Command, len(e.Commands)),
+		Needs:       e.Needs,
+		Interpreter: e.Interpreter,
+		Workdir:     e.Workdir,
+		Target:      e.Target,
+		Timeout:     types.Duration(e.Timeout),
+		FailurePolicy: types.FailurePolicy{
+			AllowFailure:   e.AllowFailure,
+			Retries:        e.Retries,
+			Backoff:        types.Duration(e.Backoff),
+			CommandTimeout: types.Duration(e.CommandTimeout),
+		},
+		Schedule: e.Schedule,
+		Watch:    e.Watch,
+	}
+	for i, c := range e.Commands {
+		r.Commands[i] = types.Command(c)
+	}
+	for _, p := range e.Parameters {
+		r.Parameters = append(r.Parameters, types.Parameter(p))
+	}
+	return r
+}
+
+// editedCommand is a command as edited in TOML: a string, or an inline
+// table with the command in "run" next to its failure policy, written like
+// the JSON form of types.Command.
+type editedCommand types.Command
+
+func (c editedCommand) MarshalTOML() ([]byte, error) {
+	// A JSON string is a TOML basic string.
+	run, err := marshalJSON(c.Run)
+	if err != nil || c.Policy == nil {
+		return run, err
+	}
+	fields := []string{"run = " + string(run)}
+	if c.Policy.AllowFailure {
+		fields = append(fields, "allow_failure = true")
+	}
+	if c.Policy.Retries != 0 {
+		fields = append(fields, fmt.Sprintf("retries = %d", c.Policy.Retries))
+	}
+	if c.Policy.Backoff != 0 {
+		fields = append(fields, fmt.Sprintf("backoff = %q", time.Duration(c.Policy.Backoff)))
+	}
+	if c.Policy.CommandTimeout != 0 {
+		fields = append(fields, fmt.Sprintf("command_timeout = %q", time.Duration(c.Policy.CommandTimeout)))
+	}
+	return []byte("{" + strings.Join(fields, ", ") + "}"), nil
+}
+
+func (c *editedCommand) UnmarshalTOML(value any) error {
+	data, err := marshalJSON(value)
+	if err != nil {
+		return err
+	}
+	return (*types.Command)(c).UnmarshalJSON(data)
+}
+
+// marshalJSON is json.Marshal without escaping &, < and >, which commands
+// are full of.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// editedDuration is a duration edited as a string such as "1m30s".
+type editedDuration types.Duration
+
+func (d editedDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *editedDuration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = editedDuration(parsed)
+	return nil
+}
+
+const editHeader = `# Edit the rune, save and quit the editor to update it.
+#
+# RuneCraft can change the name, the description and the commands. Commands
+# can't contain ";", and changed ones can't keep a failure policy of their
+# own, written as a table such as {run = "make test", retries = 2}. The other
+# settings are shown for reference: change them in the spellbook on the
+# RuneCraft host.
+
+`
+
+// runeEditCommand opens a rune in $EDITOR as TOML and updates it with the
+// result.
+func runeEditCommand(cfg *config.Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: catalyst rune edit NAME\n")
+		return 2
+	}
+	sb, dir, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	r, ok := sb.Rune(args[0])
+	if !ok {
+		return printed(fmt.Errorf("no rune named %q in spellbook %s", args[0], sb.Name))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(editHeader)
+	if err := toml.NewEncoder(&buf).Encode(newEditedRune(r)); err != nil {
+		return printed(err)
+	}
+	f, err := os.CreateTemp("", "catalyst-rune-*.toml")
+	if err != nil {
+		return printed(err)
+	}
+	path := f.Name()
+	_, err = f.Write(buf.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = openEditor(path)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return printed(err)
+	}
+
+	var edited editedRune
+	if _, err := toml.DecodeFile(path, &edited); err != nil {
+		// Keep the file so the edits aren't lost.
+		return printed(fmt.Errorf("invalid rune in %s: %w", path, err))
+	}
+	_ = os.Remove(path)
+
+	updated := edited.rune()
+	if updated.Name == "" || updated.Description == "" || len(updated.Commands) == 0 {
+		return printed(errors.New("name, description, and at least one command are required"))
+	}
+	if sameRune(r, updated) {
+		fmt.Printf("No changes to rune %s\n", r.Name)
+		return 0
+	}
+	if err := spellbook.UpdateRune(ssh.NewClient(cfg.RuneCraftHost), dir, r, updated); err != nil {
+		return printed(err)
+	}
+	fmt.Printf("Updated rune %s\n", updated.Name)
+	return 0
+}
+
+// sameRune reports whether a and b are the same rune, comparing them the way
+// they are sent to RuneCraft.
+func sameRune(a, b types.Rune) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// openEditor edits path with $VISUAL or $EDITOR, falling back to vi.
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := append(strings.Fields(editor), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
+
+// loegCommand sets and removes loegs of the spellbook.
+func loegCommand(cfg *config.Config, args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  catalyst loeg set KEY VALUE\n")
+		fmt.Fprintf(os.Stderr, "  catalyst loeg rm KEY\n")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return printed(err)
+	}
+	client := ssh.NewClient(cfg.RuneCraftHost)
+	switch {
+	case args[0] == "set" && len(args) == 3:
+		if err := spellbook.SetLoeg(client, dir, args[1], args[2]); err != nil {
+			return printed(err)
+		}
+		fmt.Printf("Set loeg %s\n", args[1])
+		return 0
+	case args[0] == "rm" && len(args) == 2:
+		if err := spellbook.RemoveLoeg(client, dir, args[1]); err != nil {
+			return printed(err)
+		}
+		fmt.Printf("Removed loeg %s\n", args[1])
+		return 0
+	}
+	usage()
+	return 2
+}
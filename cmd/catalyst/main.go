@@ -16,6 +16,7 @@ const version = "0.1.0"
 
 func main() {
 	versionFlag := flag.Bool("version", false, "Print version and exit")
+	flag.Usage = usage
 	flag.Parse()
 
 	if *versionFlag {
@@ -32,6 +33,12 @@ func main() {
 	if err != nil {
 		log.Fatalf("could not initialize database: %v", err)
 	}
+
+	if flag.NArg() > 0 {
+		code := runCommand(cfg, db, flag.Args())
+		db.Close()
+		os.Exit(code)
+	}
 	defer db.Close()
 
 	m := app.NewModel(cfg, db, version)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"catalyst/internal/app"
+	"catalyst/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+//go:embed shell
+var shellSnippets embed.FS
+
+// pickCommand lets the user pick a rune in a compact list drawn on the
+// terminal and prints its commands, rendered, to stdout. It exits with 1
+// when nothing is picked.
+func pickCommand(cfg *config.Config, args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: catalyst pick\n")
+		return 2
+	}
+	sb, _, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	if len(sb.Runes) == 0 {
+		return printed(fmt.Errorf("spellbook %s has no runes", sb.Name))
+	}
+
+	// Stdout is kept for the commands, which shell widgets capture.
+	picker := app.NewPicker(sb.Runes, cfg.ShellCommand())
+	p := tea.NewProgram(picker, tea.WithOutput(os.Stderr), tea.WithInputTTY())
+	if _, err := p.Run(); err != nil {
+		return printed(err)
+	}
+	r, ok := picker.Picked()
+	if !ok {
+		return 1
+	}
+	script, err := sb.Script(r)
+	if err != nil {
+		return printed(err)
+	}
+	fmt.Println(strings.Join(script, "\n"))
+	return 0
+}
+
+// initCommand prints the snippet that binds the rune picker to a key in the
+// given shell.
+func initCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: catalyst init zsh|bash|fish\n")
+		return 2
+	}
+	snippet, err := shellSnippets.ReadFile("shell/catalyst." + args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: unsupported shell %q, use zsh, bash or fish\n", args[0])
+		return 2
+	}
+	_, _ = os.Stdout.Write(snippet)
+	return 0
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"catalyst/internal/config"
+	"catalyst/internal/db"
+	"catalyst/internal/query"
+	"catalyst/internal/types"
+)
+
+// queryFlags creates the flag set of a query command, with the output format
+// flags.
+func queryFlags(name, usage string) (*flag.FlagSet, *query.Format) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &query.Format{}
+	fs.BoolVar(&f.JSON, "json", false, "Print JSON")
+	fs.StringVar(&f.Template, "format", "", "Print each item through a Go `template`, e.g. '{{.Name}}'")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\n\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs, f
+}
+
+// printed turns the error of printing an answer into an exit code.
+func printed(err error) int {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "catalyst: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runesCommand lists the runes of the spellbook.
+func runesCommand(cfg *config.Config, args []string) int {
+	fs, format := queryFlags("runes", "Usage: catalyst runes [--json | --format TEMPLATE]")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	sb, _, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	return printed(query.PrintList(os.Stdout, *format, sb.Runes, []query.Column[types.Rune]{
+		{Header: "NAME", Value: func(r types.Rune) string { return r.Name }},
+		{Header: "DESCRIPTION", Value: func(r types.Rune) string { return r.Description }},
+		{Header: "COMMANDS", Value: func(r types.Rune) string { return strconv.Itoa(len(r.Commands)) }},
+		{Header: "NEEDS", Value: func(r types.Rune) string { return strings.Join(r.Needs, ",") }},
+		{Header: "SCHEDULE", Value: func(r types.Rune) string { return r.Schedule }},
+	}))
+}
+
+// loegsCommand lists the loegs of the spellbook.
+func loegsCommand(cfg *config.Config, args []string) int {
+	fs, format := queryFlags("loegs", "Usage: catalyst loegs [--json | --format TEMPLATE]")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	sb, _, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	return printed(query.PrintList(os.Stdout, *format, query.Loegs(sb), []query.Column[query.Loeg]{
+		{Header: "NAME", Value: func(l query.Loeg) string { return l.Name }},
+		{Header: "VALUE", Value: func(l query.Loeg) string { return l.Value }},
+	}))
+}
+
+// historyCommand lists recorded executions, of every spellbook.
+func historyCommand(cfg *config.Config, database *db.Database, args []string) int {
+	fs, format := queryFlags("history", "Usage: catalyst history [-n N] [-spellbook NAME] [--json | --format TEMPLATE]")
+	limit := fs.Int("n", 20, "Show the latest `N` executions, 0 for all")
+	spellbookName := fs.String("spellbook", "", "Show the executions of the spellbook `NAME` only")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	executions, err := query.History(database, *spellbookName, *limit)
+	if err != nil {
+		return printed(err)
+	}
+	return printed(query.PrintList(os.Stdout, *format, executions, []query.Column[query.Execution]{
+		{Header: "ID", Value: func(e query.Execution) string { return strconv.Itoa(e.ID) }},
+		{Header: "EXECUTED AT", Value: func(e query.Execution) string { return e.ExecutedAt.Format("2006-01-02 15:04:05") }},
+		{Header: "SPELLBOOK", Value: func(e query.Execution) string { return e.Spellbook }},
+		{Header: "RUNES", Value: func(e query.Execution) string { return strings.Join(e.Runes, ",") }},
+		{Header: "STATUS", Value: func(e query.Execution) string { return e.Status }},
+		{Header: "TRIGGER", Value: func(e query.Execution) string { return e.Trigger }},
+	}))
+}
+
+// showCommand shows a rune of the spellbook.
+func showCommand(cfg *config.Config, args []string) int {
+	fs, format := queryFlags("show", "Usage: catalyst show [--json | --format TEMPLATE] RUNE")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	sb, _, err := loadSpellbook(cfg)
+	if err != nil {
+		return printed(err)
+	}
+	detail, err := query.Show(sb, fs.Arg(0))
+	if err != nil {
+		return printed(err)
+	}
+	return printed(query.PrintItem(os.Stdout, *format, detail, printRuneDetail))
+}
+
+// printRuneDetail prints what the rune detail pane shows, as plain text.
+func printRuneDetail(w io.Writer, d query.RuneDetail) error {
+	fmt.Fprintf(w, "Name:         %s\n", d.Name)
+	fmt.Fprintf(w, "Description:  %s\n", d.Description)
+	if d.Interpreter != "" {
+		fmt.Fprintf(w, "Interpreter:  %s\n", d.Interpreter)
+	}
+	if d.Workdir != "" {
+		fmt.Fprintf(w, "Workdir:      %s\n", d.Workdir)
+	}
+	if d.Target != "" {
+		fmt.Fprintf(w, "Target:       %s\n", d.Target)
+	}
+	if d.Timeout > 0 {
+		fmt.Fprintf(w, "Timeout:      %s\n", time.Duration(d.Timeout))
+	}
+	if d.Schedule != "" {
+		fmt.Fprintf(w, "Schedule:     %s\n", d.Schedule)
+	}
+	if len(d.Watch) > 0 {
+		fmt.Fprintf(w, "Watch:        %s\n", strings.Join(d.Watch, " "))
+	}
+	if len(d.Plan) > 1 {
+		fmt.Fprintf(w, "Runs:         %s\n", strings.Join(d.Plan, " → "))
+	}
+	if len(d.Parameters) > 0 {
+		fmt.Fprintf(w, "\nParameters:\n")
+		for _, p := range d.Parameters {
+			fmt.Fprintf(w, "  %s", p.Name)
+			if p.Description != "" {
+				fmt.Fprintf(w, ": %s", p.Description)
+			}
+			if len(p.Choices) > 0 {
+				fmt.Fprintf(w, " (one of %s)", strings.Join(p.Choices, ", "))
+			}
+			if p.Default != "" {
+				fmt.Fprintf(w, ", default %s", p.Default)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	fmt.Fprintf(w, "\nCommands:\n")
+	for _, cmd := range d.Rendered {
+		fmt.Fprintf(w, "  %s\n", cmd)
+	}
+	return nil
+}
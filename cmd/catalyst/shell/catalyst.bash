@@ -0,0 +1,14 @@
+# Catalyst rune picker for bash. Add to ~/.bashrc:
+#   eval "$(catalyst init bash)"
+# Alt+R opens the picker and inserts the commands of the picked rune at the
+# cursor, ready to be edited before running them.
+
+__catalyst_pick() {
+  local commands
+  commands="$(command catalyst pick </dev/tty)"
+  if [[ -n "$commands" ]]; then
+    READLINE_LINE="${READLINE_LINE:0:READLINE_POINT}${commands}${READLINE_LINE:READLINE_POINT}"
+    READLINE_POINT=$((READLINE_POINT + ${#commands}))
+  fi
+}
+bind -x '"\er": __catalyst_pick'
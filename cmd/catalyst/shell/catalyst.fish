@@ -0,0 +1,13 @@
+# Catalyst rune picker for fish. Add to ~/.config/fish/config.fish:
+#   catalyst init fish | source
+# Alt+R opens the picker and inserts the commands of the picked rune at the
+# cursor, ready to be edited before running them.
+
+function __catalyst_pick
+    set -l commands (command catalyst pick </dev/tty | string collect)
+    if test -n "$commands"
+        commandline --insert -- $commands
+    end
+    commandline --function repaint
+end
+bind \er __catalyst_pick
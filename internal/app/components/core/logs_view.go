@@ -87,6 +87,29 @@ func (m *LogsViewModel) GetContent() string {
 	return m.logOutput.String()
 }
 
+// SetContent replaces the logs with previously recorded content.
+func (m *LogsViewModel) SetContent(content string) {
+	m.logOutput.Reset()
+	m.logOutput.WriteString(content)
+	m.viewport.SetContent(content)
+	m.viewport.GotoTop()
+}
+
+// Search highlights the matches of query and returns how many there are.
+func (m *LogsViewModel) Search(query string) int {
+	return HighlightMatches(&m.viewport, query, m.theme)
+}
+
+// NextMatch scrolls to the next match of the last search.
+func (m *LogsViewModel) NextMatch() {
+	m.viewport.HighlightNext()
+}
+
+// PreviousMatch scrolls to the previous match of the last search.
+func (m *LogsViewModel) PreviousMatch() {
+	m.viewport.HighlightPrevious()
+}
+
 func (m *LogsViewModel) Resize(width, availableHeight int) {
 	m.width = width
 	m.height = availableHeight
@@ -3,6 +3,8 @@ package core
 import (
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 
 	"catalyst/internal/app/styles"
 	"catalyst/internal/types"
@@ -14,6 +16,7 @@ import (
 type RunesListDelegate struct {
 	list.DefaultDelegate
 	Theme styles.Theme
+	Shell string
 }
 
 func (d RunesListDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
@@ -31,16 +34,31 @@ func (d RunesListDelegate) Render(w io.Writer, m list.Model, index int, listItem
 		queue = ""
 	}
 
+	interpreter := baseStyle.Foreground(theme.FgSubtle).Render(InterpreterName(item.Interpreter, d.Shell))
+
 	if index == m.Index() {
 		cursor := baseStyle.Foreground(theme.Accent).Render("❯")
 		renderedTitle := baseStyle.Foreground(theme.Primary).Render(item.Title())
 		rendererQueue := baseStyle.Foreground(theme.Secondary).Render(queue)
-		fmt.Fprintf(w, "%s %s %s", cursor, renderedTitle, rendererQueue)
+		fmt.Fprintf(w, "%s %s %s %s", cursor, renderedTitle, interpreter, rendererQueue)
 	} else {
 		renderedTitle := baseStyle.Foreground(d.Theme.Blur).Render(item.Title())
 		rendererQueue := baseStyle.Foreground(theme.Secondary).Render(queue)
-		fmt.Fprintf(w, "  %s %s", renderedTitle, rendererQueue)
+		fmt.Fprintf(w, "  %s %s %s", renderedTitle, interpreter, rendererQueue)
+	}
+}
+
+// InterpreterName returns a short label for the program that runs a rune,
+// e.g. "python3" for "/usr/bin/python3 -c".
+func InterpreterName(interpreter, shell string) string {
+	fields := strings.Fields(interpreter)
+	if len(fields) == 0 {
+		fields = strings.Fields(shell)
+	}
+	if len(fields) == 0 {
+		return ""
 	}
+	return filepath.Base(fields[0])
 }
 
 type RuneItem struct {
@@ -52,13 +70,13 @@ func (i RuneItem) Title() string       { return i.Rune.Name }
 func (i RuneItem) Description() string { return i.Rune.Description }
 func (i RuneItem) FilterValue() string { return i.Rune.Name }
 
-func NewRunesList(theme styles.Theme, runes []types.Rune) list.Model {
+func NewRunesList(theme styles.Theme, runes []types.Rune, shell string) list.Model {
 	items := make([]list.Item, len(runes))
 	for i, r := range runes {
 		items[i] = RuneItem{Rune: r}
 	}
 
-	runesList := list.New(items, RunesListDelegate{Theme: theme}, 0, 0)
+	runesList := list.New(items, RunesListDelegate{Theme: theme, Shell: shell}, 0, 0)
 	runesList.SetShowHelp(false)
 	runesList.SetShowTitle(false)
 	runesList.SetShowStatusBar(false)
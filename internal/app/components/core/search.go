@@ -0,0 +1,42 @@
+package core
+
+import (
+	"strings"
+
+	"catalyst/internal/app/styles"
+
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// HighlightMatches highlights the case-insensitive matches of query in the
+// content of vp and scrolls to the first one at or below the current
+// position. It returns the number of matches.
+func HighlightMatches(vp *viewport.Model, query string, theme *styles.Theme) int {
+	vp.ClearHighlights()
+	if query == "" {
+		return 0
+	}
+	vp.HighlightStyle = lipgloss.NewStyle().
+		Foreground(theme.Black).
+		Background(theme.FgSubtle)
+	vp.SelectedHighlightStyle = lipgloss.NewStyle().
+		Foreground(theme.Black).
+		Background(theme.Accent)
+
+	content := strings.ToLower(ansi.Strip(vp.GetContent()))
+	query = strings.ToLower(query)
+	var matches [][]int
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], query)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		matches = append(matches, []int{start, start + len(query)})
+		offset = start + len(query)
+	}
+	vp.SetHighlights(matches)
+	return len(matches)
+}
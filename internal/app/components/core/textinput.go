@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"catalyst/internal/app/styles"
+	"catalyst/internal/types"
 
 	"github.com/charmbracelet/bubbles/v2/textinput"
 	tea "github.com/charmbracelet/bubbletea/v2"
@@ -14,6 +15,10 @@ type CustomTextInput struct {
 	textinput.Model
 	Name  string
 	Theme styles.Theme
+	// Policy is the failure policy of the rune command edited in the input,
+	// if it has one. It follows the command when commands are added,
+	// removed or moved.
+	Policy *types.FailurePolicy
 }
 
 // NewTextInput creates a new CustomTextInput.
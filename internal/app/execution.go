@@ -0,0 +1,931 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"catalyst/internal/app/components/core"
+	"catalyst/internal/app/components/statusbar"
+	"catalyst/internal/db"
+	"catalyst/internal/execution"
+	"catalyst/internal/local"
+	"catalyst/internal/loeg"
+	"catalyst/internal/runlog"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/ssh"
+	"catalyst/internal/terminal"
+	"catalyst/internal/types"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/log/v2"
+)
+
+// runeExecution is a single rune being executed, with its own output buffer.
+type runeExecution struct {
+	id       int
+	job      *job
+	rune     types.Rune
+	steps    []spellbook.Step // Commands of the rune, sub-runes expanded
+	needs    []*runeExecution // Executions that must succeed first
+	status   execution.Status
+	index    int  // Index of the current step
+	attempt  int  // Retries of the current command so far
+	canceled bool // Canceled by the user
+
+	limits  execution.Limits  // Timeouts of the rune
+	current execution.Attempt // Timeout of the current attempt
+
+	cancel   context.CancelFunc
+	msgChan  chan tea.Msg
+	terminal types.Terminal   // Terminal of the running command
+	process  types.Process    // Process group of the running command
+	screen   *terminal.Screen // Emulates the terminal the output was written to
+	totals   execTotals
+	plan     string   // What a dry run would do
+	outFile  *os.File // Stores the raw output
+}
+
+// executionMsg carries a message from the runner of one execution.
+type executionMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// ready reports whether every execution e needs has succeeded.
+func (e *runeExecution) ready() bool {
+	for _, n := range e.needs {
+		if n.status != execution.Succeeded {
+			return false
+		}
+	}
+	return true
+}
+
+// failedNeed returns an execution e needs that didn't succeed and won't, or
+// nil.
+func (e *runeExecution) failedNeed() *runeExecution {
+	for _, n := range e.needs {
+		switch n.status {
+		case execution.Failed, execution.TimedOut, execution.Canceled, execution.Skipped:
+			return n
+		}
+	}
+	return nil
+}
+
+// policy returns the failure policy of the current step.
+func (e *runeExecution) policy() types.FailurePolicy {
+	if e.index < len(e.steps) {
+		return e.steps[e.index].Policy
+	}
+	return types.FailurePolicy{}
+}
+
+// countdownMsg refreshes the timeout countdown of the executing view while
+// job id runs.
+type countdownMsg struct{ id int }
+
+// pendingRun is a run waiting for the values of its parameters.
+type pendingRun struct {
+	runes  []types.Rune
+	params []types.Parameter
+	dryRun bool
+}
+
+// runRunes executes runes, or dry-runs them, once the values of their
+// parameters are known. Parameters are asked for in a form that starts with
+// the values in prefill, or with the defaults. m.previousState is where the
+// user goes back to.
+func (m *Model) runRunes(runes []types.Rune, dryRun bool, prefill map[string]string) tea.Cmd {
+	runes, err := m.spellbook.Plan(runes)
+	if err != nil {
+		m.StatusBar.Content = err.Error()
+		m.StatusBar.Level = statusbar.LevelError
+		return clearStatusCmd()
+	}
+
+	params := types.RuneParameters(m.spellbook.WithSubRunes(runes))
+	if len(params) == 0 {
+		return m.beginRun(runes, dryRun, nil)
+	}
+
+	m.pendingRun = &pendingRun{runes: runes, params: params, dryRun: dryRun}
+	m.state = promptingParameters
+	m.keys = formKeys()
+	m.inputs = make([]core.CustomTextInput, len(params))
+	m.focusIndex = 0
+	for i, p := range params {
+		t := core.NewTextInput(p.Name, *m.Theme)
+		t.Model.Placeholder = p.Description
+		if len(p.Choices) > 0 {
+			t.Model.Placeholder = strings.Join(p.Choices, " | ")
+			t.Model.SetSuggestions(p.Choices)
+		}
+		value, ok := prefill[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		t.Model.SetValue(value)
+		if i == 0 {
+			t.Model.Focus()
+		}
+		m.inputs[i] = t
+	}
+	m.StatusBar.Content = "Fill in the parameters"
+	m.StatusBar.Level = statusbar.LevelInfo
+	return textinput.Blink
+}
+
+// submitParameters starts the pending run with the values of the parameters
+// form, or reports the first invalid one.
+func (m *Model) submitParameters() tea.Cmd {
+	run := m.pendingRun
+	values := make(map[string]string, len(run.params))
+	for i, p := range run.params {
+		value := strings.TrimSpace(m.inputs[i].Model.Value())
+		if err := p.Validate(value); err != nil {
+			m.StatusBar.Content = err.Error()
+			m.StatusBar.Level = statusbar.LevelError
+			return clearStatusCmd()
+		}
+		values[p.Name] = value
+	}
+	m.pendingRun = nil
+	return m.beginRun(run.runes, run.dryRun, values)
+}
+
+// beginRun starts runes with the given parameter values in a new job and
+// shows it in the executing view.
+func (m *Model) beginRun(runes []types.Rune, dryRun bool, params map[string]string) tea.Cmd {
+	m.focusedElement = logsViewportElement
+	if dryRun {
+		return m.startDryRun(runes, params)
+	}
+	m.executionQueue = nil
+	return m.startExecution(runes, params)
+}
+
+// commandValues returns the values the rune commands of j are rendered
+// with: the loegs, overridden by the parameters of the job.
+func (m *Model) commandValues(j *job) map[string]string {
+	values := maps.Clone(m.spellbook.Loegs)
+	if values == nil {
+		values = make(map[string]string, len(j.params))
+	}
+	maps.Copy(values, j.params)
+	return values
+}
+
+// newExecutions creates the executions of runes in j.
+func (m *Model) newExecutions(j *job, runes []types.Rune) {
+	j.executions = make([]*runeExecution, len(runes))
+	for i, r := range runes {
+		m.nextExecutionID++
+		j.executions[i] = &runeExecution{
+			id:     m.nextExecutionID,
+			job:    j,
+			rune:   r,
+			screen: terminal.New(m.executingViewport.Width(), m.executingViewport.Height()),
+		}
+	}
+}
+
+// startExecution runs runes in a new job shown in the executing view.
+func (m *Model) startExecution(runes []types.Rune, params map[string]string) tea.Cmd {
+	j, err := m.startJob(runes, params, db.TriggerManual)
+	if err != nil {
+		return func() tea.Msg { return errMsg{err} }
+	}
+	m.attachJob(j)
+	return m.runJob(j)
+}
+
+// startJob creates a job running runes, one after another or, in parallel
+// mode, up to the configured concurrency at a time, and records it in the
+// history with the given trigger. The job starts with runJob.
+func (m *Model) startJob(runes []types.Rune, params map[string]string, trigger string) (*job, error) {
+	var runeIDs []string
+	for _, r := range runes {
+		runeIDs = append(runeIDs, r.Name)
+	}
+	historyID, err := m.db.AddHistoryEntry(runeIDs, m.spellbook.Name, trigger, params)
+	if err != nil {
+		return nil, err
+	}
+
+	j := m.newJob(true)
+	j.historyID = historyID
+	j.params = params
+	j.trigger = trigger
+	m.newExecutions(j, runes)
+	if trigger != db.TriggerManual {
+		j.logsView.AddLog(log.InfoLevel, "Execution triggered", "trigger", trigger)
+	}
+	m.loadDotenv(j)
+	m.openRun(j)
+	if j.run != nil {
+		for i, e := range j.executions {
+			if e.outFile, err = j.run.CreateOutput(i); err != nil {
+				m.logExecution(e, log.WarnLevel, "Output won't be stored", "error", err)
+			}
+		}
+	}
+	linkNeeds(j.executions)
+	j.parallel = m.parallelQueue && len(runes) > 1
+	if j.parallel {
+		j.logsView.AddLog(
+			log.InfoLevel,
+			"Running queue in parallel",
+			"runes",
+			len(runes),
+			"concurrency",
+			m.concurrency,
+		)
+	}
+	return j, nil
+}
+
+// runJob starts the executions of j.
+func (m *Model) runJob(j *job) tea.Cmd {
+	return tea.Batch(m.StatusBar.StartSpinner(), m.scheduleExecutions(j), countdownCmd(j))
+}
+
+// countdownCmd refreshes the executing view every second while j runs.
+func countdownCmd(j *job) tea.Cmd {
+	id := j.id
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return countdownMsg{id: id} })
+}
+
+// remaining returns how long the current attempt of e may still run, and
+// whether it has a deadline at all.
+func (e *runeExecution) remaining() (time.Duration, bool) {
+	if e.status != execution.Running || e.current.Deadline.IsZero() {
+		return 0, false
+	}
+	return max(time.Until(e.current.Deadline), 0), true
+}
+
+// scheduleExecutions starts pending executions of j while there is room for
+// them.
+func (m *Model) scheduleExecutions(j *job) tea.Cmd {
+	limit := 1
+	if j.parallel {
+		limit = max(m.concurrency, 1)
+	}
+	running := 0
+	for _, e := range j.executions {
+		if e.status == execution.Running {
+			running++
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i, e := range j.executions {
+		if e.status != execution.Pending {
+			continue
+		}
+		if n := e.failedNeed(); n != nil {
+			e.status = execution.Skipped
+			m.logExecution(e, log.WarnLevel, "Rune skipped", "needs", n.rune.Name, "reason", n.status)
+			continue
+		}
+		if running >= limit || !e.ready() {
+			continue
+		}
+		e.status = execution.Running
+		steps, err := m.spellbook.Expand(e.rune)
+		e.steps = steps
+		e.limits = execution.NewLimits(e.rune, m.commandTimeout, time.Now())
+		running++
+		if !j.parallel {
+			// Follow the running rune.
+			j.shown = i
+			if j == m.job {
+				m.showExecution(i)
+			}
+		}
+		host, _ := m.runeLocation(e.rune)
+		if host == "" {
+			host = "local"
+		}
+		m.logExecution(
+			e,
+			log.DebugLevel,
+			"Execution started",
+			"interpreter",
+			m.runeInterpreter(e.rune),
+			"target",
+			host,
+		)
+		id := e.id
+		if err != nil {
+			cmds = append(cmds, func() tea.Msg {
+				return executionMsg{id: id, msg: types.RuneCommandFinished{Err: err, ExitCode: -1}}
+			})
+			continue
+		}
+		cmds = append(cmds, func() tea.Msg { return runNextCommandMsg{id: id} })
+	}
+	if running == 0 {
+		// Everything left was skipped.
+		return m.executionDone(j)
+	}
+	return tea.Batch(cmds...)
+}
+
+// linkNeeds points each execution to the executions of the runes it needs.
+func linkNeeds(executions []*runeExecution) {
+	byName := make(map[string]*runeExecution, len(executions))
+	for _, e := range executions {
+		byName[e.rune.Name] = e
+	}
+	for _, e := range executions {
+		for _, name := range e.rune.Needs {
+			if n, ok := byName[name]; ok && n != e {
+				e.needs = append(e.needs, n)
+			}
+		}
+	}
+}
+
+// openRun prepares storing the output of j. Jobs still run when their output
+// can't be stored.
+func (m *Model) openRun(j *job) {
+	if m.dataDir == "" {
+		return
+	}
+	run, err := runlog.Create(m.dataDir, j.historyID)
+	if err == nil {
+		err = m.db.SetHistoryOutputDir(j.historyID, run.Dir)
+	}
+	if err != nil {
+		j.logsView.AddLog(log.WarnLevel, "Output won't be stored", "error", err)
+		return
+	}
+	j.run = run
+}
+
+// saveRun stores the log and manifest of j and closes its output files.
+func (m *Model) saveRun(j *job) {
+	if j.run == nil {
+		return
+	}
+	runes := make([]runlog.Rune, len(j.executions))
+	for i, e := range j.executions {
+		if e.outFile != nil {
+			_ = e.outFile.Close()
+			e.outFile = nil
+		}
+		runes[i] = runlog.Rune{Name: e.rune.Name, Status: e.status.String(), Output: runlog.OutputFile(i)}
+	}
+	err := j.run.WriteManifest(runes)
+	if err == nil {
+		err = j.run.WriteLog(j.logsView.GetContent())
+	}
+	if err != nil {
+		j.logsView.AddLog(log.WarnLevel, "Failed to store execution output", "error", err)
+	}
+	j.run = nil
+}
+
+// execution returns the running execution with the given id, or nil if its
+// job is over.
+func (m *Model) execution(id int) *runeExecution {
+	for _, j := range m.jobs {
+		if j.done() {
+			continue
+		}
+		for _, e := range j.executions {
+			if e.id == id {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// shown returns the execution whose output is displayed, if any.
+func (m *Model) shown() *runeExecution {
+	if m.job == nil {
+		return nil
+	}
+	return m.job.shownExecution()
+}
+
+// showExecution displays the output of the execution of the attached job at
+// index i.
+func (m *Model) showExecution(i int) {
+	j := m.job
+	if j == nil || len(j.executions) == 0 {
+		return
+	}
+	j.shown = (i + len(j.executions)) % len(j.executions)
+	m.executingViewport.SetContent(j.executions[j.shown].screen.Render())
+	m.executingViewport.GotoBottom()
+}
+
+// cancelExecution stops the running command of e. Canceling an execution
+// that is already being stopped kills its process group right away.
+func (m *Model) cancelExecution(e *runeExecution) {
+	if e.canceled && e.process != nil {
+		m.logExecution(e, log.WarnLevel, "Killing command", "signal", syscall.SIGKILL)
+		if err := e.process.Kill(); err != nil {
+			m.logExecution(e, log.ErrorLevel, "Failed to kill command", "error", err)
+		}
+		return
+	}
+	e.canceled = true
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// runeLocation returns the ssh host the commands of r run on, empty when
+// they run locally, and the directory they run in.
+func (m *Model) runeLocation(r types.Rune) (host, dir string) {
+	host = m.cfg.TargetHost(r.Target)
+	if host != "" {
+		return host, r.Workdir
+	}
+	return "", local.WorkDir(m.pwd, r.Workdir)
+}
+
+// execOptions returns how the commands of r are run in j.
+func (m *Model) execOptions(j *job, r types.Rune) []local.ExecOption {
+	host, dir := m.runeLocation(r)
+	options := []local.ExecOption{
+		local.WithInterpreter(r.Interpreter),
+		local.WithDir(dir),
+		local.WithEnv(loeg.Environ(loeg.Merge(nil, j.dotenv, m.spellbook.Loegs))),
+	}
+	if host != "" {
+		options = append(options, local.WithTarget(host))
+	}
+	return options
+}
+
+// loadDotenv loads the .env file of the spellbook directory for j, if
+// enabled.
+func (m *Model) loadDotenv(j *job) {
+	if !m.cfg.Dotenv {
+		return
+	}
+	dotenv, err := loeg.LoadDotenv(m.pwd)
+	if err != nil {
+		j.logsView.AddLog(log.WarnLevel, "Ignoring .env file", "error", err)
+		return
+	}
+	j.dotenv = dotenv
+	j.logsView.AddLog(
+		log.DebugLevel,
+		"Environment",
+		loeg.SourceDotenv,
+		len(dotenv),
+		"loegs",
+		len(m.spellbook.Loegs),
+	)
+}
+
+// runeInterpreter describes the interpreter the commands of r run with.
+func (m *Model) runeInterpreter(r types.Rune) string {
+	if host, _ := m.runeLocation(r); host != "" && r.Interpreter == "" {
+		return "remote login shell"
+	}
+	return m.localRunner.Interpreter(r.Interpreter)
+}
+
+// logExecution adds a log line tagged with the rune of e.
+func (m *Model) logExecution(e *runeExecution, level log.Level, msg string, keyvals ...any) {
+	e.job.logsView.AddLog(level, msg, append([]any{"rune", e.rune.Name}, keyvals...)...)
+}
+
+// logStep adds a log line about the current step of e, naming the sub-rune
+// it comes from.
+func (m *Model) logStep(e *runeExecution, level log.Level, msg string, keyvals ...any) {
+	origin := e.rune.Name
+	if e.index < len(e.steps) {
+		origin = e.steps[e.index].Origin()
+	}
+	e.job.logsView.AddLog(level, msg, append([]any{"rune", origin}, keyvals...)...)
+}
+
+// executeNextCommandCmd starts the current command of e and returns a command
+// that listens for its output.
+func (m *Model) executeNextCommandCmd(e *runeExecution) tea.Cmd {
+	id := e.id
+	if e.index >= len(e.steps) {
+		return func() tea.Msg { return executionMsg{id: id, msg: types.RuneCommandFinished{}} }
+	}
+	step := e.steps[e.index]
+
+	command, err := loeg.Render(step.Command, m.commandValues(e.job))
+	if err != nil {
+		m.logStep(e, log.ErrorLevel, "Command not run", "error", err)
+		return func() tea.Msg {
+			return executionMsg{id: id, msg: types.RuneCommandFinished{Err: err, ExitCode: -1}}
+		}
+	}
+
+	e.current = e.limits.Attempt(time.Now(), step.Policy)
+	if e.current.Expired(time.Now()) {
+		// The rune ran out of time while waiting to retry.
+		return func() tea.Msg {
+			return executionMsg{id: id, msg: types.RuneCommandFinished{ExitCode: -1, TimedOut: true}}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if !e.current.Deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(context.Background(), e.current.Deadline)
+	}
+	e.cancel = cancel
+
+	cols, rows := e.screen.Size()
+	options := append(m.execOptions(e.job, step.Rune), local.WithSize(cols, rows))
+	msgChan := make(chan tea.Msg)
+	e.msgChan = msgChan
+	go func() {
+		defer close(msgChan)
+		m.localRunner.ExecuteCommand(ctx, command, msgChan, options...)
+	}()
+
+	return waitForOutput(id, msgChan)
+}
+
+// updateExecution handles a message from the runner of e.
+func (m *Model) updateExecution(e *runeExecution, msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case types.RuneCommandStarted:
+		e.terminal = msg.Terminal
+		e.process = msg.Process
+		return waitForOutput(e.id, e.msgChan)
+
+	case types.RuneCommandSignaled:
+		m.logExecution(e, log.WarnLevel, "Stopping command", "signal", msg.Signal)
+		return waitForOutput(e.id, e.msgChan)
+
+	case types.RuneCommandCursorQuery:
+		if e.terminal != nil {
+			x, y := e.screen.Cursor()
+			prefix := ""
+			if msg.Extended {
+				prefix = "?"
+			}
+			_, _ = fmt.Fprintf(e.terminal, "\x1b[%s%d;%dR", prefix, y+1, x+1)
+		}
+		return waitForOutput(e.id, e.msgChan)
+
+	case types.RuneCommandOutputMsg:
+		_, _ = e.screen.Write([]byte(msg.Output))
+		if e.outFile != nil {
+			_, _ = e.outFile.WriteString(msg.Output)
+		}
+		if e == m.shown() {
+			m.executingViewport.SetContent(e.screen.Render())
+			m.executingViewport.GotoBottom()
+		}
+		return waitForOutput(e.id, e.msgChan)
+
+	case types.RuneCommandFinished:
+		return m.commandFinished(e, msg)
+	}
+	return nil
+}
+
+// commandFinished applies the failure policy of the command that just
+// finished and moves on to the next command or execution.
+func (m *Model) commandFinished(e *runeExecution, msg types.RuneCommandFinished) tea.Cmd {
+	e.cancel = nil
+	e.terminal = nil
+	e.process = nil
+	e.totals.add(msg)
+	e.job.totals.add(msg)
+	msg = e.current.Finished(msg)
+
+	outcome := execution.Decide(e.policy(), e.attempt, msg, e.canceled, e.limits.Expired(time.Now()))
+	switch {
+	case outcome.Action == execution.Retry:
+		e.attempt++
+		m.logStep(
+			e,
+			log.WarnLevel,
+			"Command failed, retrying",
+			append([]any{"error", msg.Err, "delay", outcome.Delay}, commandResultKeyvals(msg)...)...,
+		)
+		id := e.id
+		return tea.Tick(outcome.Delay, func(time.Time) tea.Msg { return runNextCommandMsg{id: id} })
+	case outcome.Allowed:
+		m.logStep(
+			e,
+			log.WarnLevel,
+			"Command failed, continuing (allow_failure)",
+			append([]any{"error", msg.Err}, commandResultKeyvals(msg)...)...,
+		)
+	case outcome.Action == execution.Next:
+		m.logStep(e, log.InfoLevel, "Command finished", commandResultKeyvals(msg)...)
+	}
+	e.attempt = 0
+
+	if outcome.Action == execution.Stop {
+		e.status = outcome.Status
+		if m.interactive && e == m.shown() {
+			m.setInteractive(false)
+		}
+		m.logStep(
+			e,
+			log.ErrorLevel,
+			"Command failed, stopping rune",
+			append([]any{"error", msg.Err, "reason", e.status}, commandResultKeyvals(msg)...)...,
+		)
+		m.logExecution(e, log.ErrorLevel, "Rune failed", append([]any{"reason", e.status}, e.totals.keyvals()...)...)
+		if !e.job.parallel && m.skipPending(e.job) > 0 {
+			e.job.logsView.AddLog(log.ErrorLevel, "Execution queue stopped due to error")
+		}
+		return m.executionDone(e.job)
+	}
+
+	e.index++
+	if e.index < len(e.steps) {
+		id := e.id
+		return func() tea.Msg { return runNextCommandMsg{id: id} }
+	}
+
+	e.status = execution.Succeeded
+	if m.interactive && e.job.parallel && e == m.shown() {
+		m.setInteractive(false)
+	}
+	m.logExecution(e, log.DebugLevel, "Rune finished", e.totals.keyvals()...)
+	return m.executionDone(e.job)
+}
+
+// skipPending marks executions of j that haven't started as skipped and
+// returns how many there were.
+func (m *Model) skipPending(j *job) int {
+	skipped := 0
+	for _, e := range j.executions {
+		if e.status == execution.Pending {
+			e.status = execution.Skipped
+			skipped++
+		}
+	}
+	return skipped
+}
+
+// executionDone starts the next executions of j, or reports the result once
+// nothing is left to run.
+func (m *Model) executionDone(j *job) tea.Cmd {
+	if j.done() {
+		return nil
+	}
+	statuses := make([]execution.Status, len(j.executions))
+	for i, e := range j.executions {
+		switch e.status {
+		case execution.Pending:
+			return m.scheduleExecutions(j)
+		case execution.Running:
+			return nil
+		}
+		statuses[i] = e.status
+	}
+	status, failed := execution.RunStatus(statuses)
+	j.status = status
+	j.finished = time.Now()
+	if err := m.db.SetHistoryStatus(j.historyID, status); err != nil {
+		j.logsView.AddLog(log.ErrorLevel, "Failed to record execution in history", "error", err)
+	}
+	defer m.saveRun(j)
+
+	if m.runningJobs() == 0 {
+		m.StatusBar.StopSpinner()
+	}
+	queue := len(j.executions) > 1
+	switch {
+	case failed > 0:
+		if queue {
+			j.logsView.AddLog(log.ErrorLevel, "Execution queue finished with failures", append([]any{"failed", failed}, j.totals.keyvals()...)...)
+		}
+	case queue:
+		j.logsView.AddLog(log.DebugLevel, "All runes in queue executed successfully", j.totals.keyvals()...)
+	}
+
+	if j != m.job {
+		m.StatusBar.Content = fmt.Sprintf("Job %d (%s) %s", j.id, j.name(), status)
+		m.StatusBar.Level = statusbar.LevelSuccess
+		if failed > 0 {
+			m.StatusBar.Level = statusbar.LevelError
+		}
+		return clearStatusCmd()
+	}
+	if m.interactive {
+		m.setInteractive(false)
+	}
+	switch {
+	case failed > 0:
+		m.StatusBar.Content = fmt.Sprintf("Execution %s!", status)
+		m.StatusBar.Level = statusbar.LevelError
+	case queue:
+		m.StatusBar.Content = "Execution queue finished"
+		m.StatusBar.Level = statusbar.LevelSuccess
+	default:
+		m.StatusBar.Content = "Execution finished"
+		m.StatusBar.Level = statusbar.LevelSuccess
+	}
+	return clearStatusCmd()
+}
+
+// waitForOutput is a tea.Cmd that waits for the next message from the
+// runner of execution id.
+func waitForOutput(id int, ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		// This will block until a message is available.
+		// Bubble Tea will handle this in its runtime.
+		msg, ok := <-ch
+		if !ok {
+			// Channel was closed, which means the command is done.
+			// We shouldn't get here if the runner sends RuneCommandFinished,
+			// but as a safeguard:
+			return executionMsg{id: id, msg: types.RuneCommandFinished{Err: nil}}
+		}
+		return executionMsg{id: id, msg: msg}
+	}
+}
+
+// startDryRun shows what executing runes would do without starting any
+// process, and records the dry run in the history.
+func (m *Model) startDryRun(runes []types.Rune, params map[string]string) tea.Cmd {
+	var runeIDs []string
+	for _, r := range runes {
+		runeIDs = append(runeIDs, r.Name)
+	}
+	historyID, err := m.db.AddHistoryEntry(runeIDs, m.spellbook.Name, db.TriggerManual, params)
+	if err == nil {
+		err = m.db.SetHistoryStatus(historyID, db.StatusDryRun)
+	}
+	if err != nil {
+		return func() tea.Msg { return errMsg{err} }
+	}
+
+	j := m.newJob(false)
+	j.historyID = historyID
+	j.params = params
+	j.dryRun = true
+	j.status = db.StatusDryRun
+	m.newExecutions(j, runes)
+	m.loadDotenv(j)
+	m.openRun(j)
+	for i, e := range j.executions {
+		e.status = execution.DryRun
+		e.plan = m.dryRunPlan(e, i, len(runes))
+		_, _ = e.screen.Write([]byte(strings.ReplaceAll(e.plan, "\n", "\r\n")))
+		if j.run != nil {
+			if err := j.run.WriteOutput(i, []byte(e.plan)); err != nil {
+				m.logExecution(e, log.WarnLevel, "Output won't be stored", "error", err)
+			}
+		}
+	}
+	m.saveRun(j)
+	m.attachJob(j)
+
+	m.StatusBar.Content = "Dry run: nothing was executed"
+	m.StatusBar.Level = statusbar.LevelInfo
+	return clearStatusCmd()
+}
+
+// dryRunPlan describes how the commands of e would run and logs each of
+// them.
+func (m *Model) dryRunPlan(e *runeExecution, i, n int) string {
+	host, dir := m.runeLocation(e.rune)
+	target := "local"
+	if host != "" {
+		target = fmt.Sprintf("%s (%s)", e.rune.Target, host)
+	}
+
+	var plan strings.Builder
+	fmt.Fprintf(&plan, "# %s (%d/%d)\n", e.rune.Name, i+1, n)
+	fmt.Fprintf(&plan, "# target:      %s\n", target)
+	fmt.Fprintf(&plan, "# dir:         %s\n", dir)
+	fmt.Fprintf(&plan, "# interpreter: %s\n", m.runeInterpreter(e.rune))
+	fmt.Fprintf(&plan, "# environment:\n")
+	// The plan is stored and can be copied, so the values of the variables,
+	// which may be credentials, are left out: inherited ones are counted,
+	// the others named with where they come from.
+	if host == "" {
+		// Commands on a target don't inherit the local environment.
+		fmt.Fprintf(&plan, "#   %d variables inherited from the %s environment\n", len(os.Environ()), loeg.SourceProcess)
+	}
+	for _, v := range loeg.Merge(nil, e.job.dotenv, m.spellbook.Loegs) {
+		fmt.Fprintf(&plan, "#   %s (%s)\n", v.Name, v.Source)
+	}
+	if len(e.rune.Parameters) > 0 {
+		fmt.Fprintf(&plan, "# parameters:\n")
+		for _, p := range e.rune.Parameters {
+			fmt.Fprintf(&plan, "#   %s=%s\n", p.Name, e.job.params[p.Name])
+		}
+	}
+	steps, err := m.spellbook.Expand(e.rune)
+	if err != nil {
+		fmt.Fprintf(&plan, "! %v\n", err)
+		m.logExecution(e, log.ErrorLevel, "Would fail", "error", err)
+		return plan.String()
+	}
+	e.steps = steps
+	// Loegs stay placeholders in the commands, for the same reason.
+	values := m.commandValues(e.job)
+	for key := range m.spellbook.Loegs {
+		if _, ok := e.job.params[key]; !ok {
+			values[key] = "{{." + key + "}}"
+		}
+	}
+	for i, s := range steps {
+		if len(s.Path) > 1 && (i == 0 || !slices.Equal(steps[i-1].Path, s.Path)) {
+			fmt.Fprintf(&plan, "# %s%s\n", spellbook.SubRunePrefix, s.Origin())
+		}
+		command, err := loeg.Render(s.Command, values)
+		if err != nil {
+			fmt.Fprintf(&plan, "! %v\n", err)
+			e.job.logsView.AddLog(log.ErrorLevel, "Would fail", "rune", s.Origin(), "error", err)
+			return plan.String()
+		}
+		_, dir := m.runeLocation(s.Rune)
+		// The variables are listed above, without their values.
+		options := append(m.execOptions(e.job, s.Rune), local.WithEnv(nil))
+		cmd := m.localRunner.Command(command, options...)
+		line := ssh.QuoteArgs(cmd.Args)
+		fmt.Fprintf(&plan, "$ %s\n", line)
+		e.job.logsView.AddLog(log.InfoLevel, "Would run", "rune", s.Origin(), "cmd", line, "dir", dir)
+	}
+	return plan.String()
+}
+
+// search highlights the matches of query in the focused view.
+func (m *Model) search(query string) tea.Cmd {
+	var matches int
+	if m.focusedElement == logsViewportElement && m.logsView != nil {
+		matches = m.logsView.Search(query)
+	} else {
+		matches = core.HighlightMatches(&m.executingViewport, query, m.Theme)
+	}
+	if query == "" {
+		return nil
+	}
+	m.StatusBar.Content = fmt.Sprintf("%d matches for %q", matches, query)
+	m.StatusBar.Level = statusbar.LevelInfo
+	if matches == 0 {
+		m.StatusBar.Level = statusbar.LevelWarning
+	}
+	return clearStatusCmd()
+}
+
+// replayHistoryEntry shows the stored output of a past execution in the
+// executing view, without running anything.
+func (m *Model) replayHistoryEntry(entry db.HistoryEntry) tea.Cmd {
+	if entry.OutputDir == "" {
+		m.StatusBar.Content = "No output was stored for this execution"
+		m.StatusBar.Level = statusbar.LevelWarning
+		return clearStatusCmd()
+	}
+	record, err := runlog.Load(entry.OutputDir, strings.Split(entry.RuneID, ","))
+	if err != nil {
+		m.StatusBar.Content = "Failed to load output: " + err.Error()
+		m.StatusBar.Level = statusbar.LevelError
+		return clearStatusCmd()
+	}
+
+	j := m.newJob(false)
+	j.replay = true
+	j.dryRun = entry.Status == db.StatusDryRun
+	j.status = entry.Status
+	j.historyID = entry.ID
+	j.params = entry.Params
+	j.started = entry.ExecutedAt
+	j.logsView.SetContent(record.Log)
+	runes := make([]types.Rune, len(record.Runes))
+	for i, r := range record.Runes {
+		runes[i] = types.Rune{Name: r.Name}
+	}
+	m.newExecutions(j, runes)
+	for i, e := range j.executions {
+		e.status = execution.ParseStatus(record.Runes[i].Status)
+		output := record.Outputs[i]
+		if j.dryRun {
+			// Plans are stored as plain text.
+			e.plan = string(output)
+			output = []byte(strings.ReplaceAll(e.plan, "\n", "\r\n"))
+		}
+		_, _ = e.screen.Write(output)
+	}
+	m.previousState = showingHistory
+	m.focusedElement = outputViewportElement
+	m.attachJob(j)
+
+	m.StatusBar.Content = fmt.Sprintf("Output of %s at %s", entry.RuneID, entry.ExecutedAt.Format("2006-01-02 15:04:05"))
+	m.StatusBar.Level = statusbar.LevelInfo
+	return nil
+}
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"catalyst/internal/app/components/core"
+	"catalyst/internal/app/components/statusbar"
+	"catalyst/internal/db"
+	"catalyst/internal/execution"
+	"catalyst/internal/runlog"
+	"catalyst/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/log/v2"
+)
+
+// maxFinishedJobs is how many finished jobs the jobs panel keeps. Older ones
+// are dropped with their output, which stays available from the history.
+const maxFinishedJobs = 20
+
+// job is one run of runes. The executing view shows a single job; jobs sent
+// to the background keep running until they finish or are canceled.
+type job struct {
+	id         int
+	started    time.Time
+	finished   time.Time
+	status     string // One of the db status constants
+	executions []*runeExecution
+	shown      int  // Index of the execution shown in the output view
+	parallel   bool // Runs its runes in parallel
+	historyID  int
+	params     map[string]string // Parameter values of the run
+	trigger    string            // What started the job, a db trigger
+	dotenv     map[string]string // The spellbook's .env, if loaded
+	dryRun     bool              // Nothing is executed
+	replay     bool              // Loaded from history
+	run        *runlog.Run       // Stored output, until the job is done
+	totals     execTotals        // Resource usage of all its runes
+	logsView   *core.LogsViewModel
+}
+
+// newJob creates a job with its own logs view. Jobs that run commands are
+// listed in the jobs panel; dry runs and replays are not.
+func (m *Model) newJob(listed bool) *job {
+	m.nextJobID++
+	j := &job{
+		id:       m.nextJobID,
+		started:  time.Now(),
+		status:   db.StatusRunning,
+		logsView: core.NewLogsView(m.width/3, m.availableHeight, m.Theme),
+	}
+	if listed {
+		m.pruneJobs()
+		m.jobs = append(m.jobs, j)
+	}
+	return j
+}
+
+// pruneJobs drops the oldest finished jobs beyond maxFinishedJobs. Running
+// jobs and the attached one are kept.
+func (m *Model) pruneJobs() {
+	finished := 0
+	for _, j := range m.jobs {
+		if j.done() {
+			finished++
+		}
+	}
+	drop := finished - (maxFinishedJobs - 1) // Room for the job about to finish
+	if drop <= 0 {
+		return
+	}
+	m.jobs = slices.DeleteFunc(m.jobs, func(j *job) bool {
+		if drop > 0 && j.done() && j != m.job {
+			drop--
+			return true
+		}
+		return false
+	})
+	if m.state == showingJobs {
+		// Keep the cursor on the panel, which is about to list one more job.
+		m.cursor = min(m.cursor, len(m.jobs))
+	}
+}
+
+// name lists the runes of j.
+func (j *job) name() string {
+	names := make([]string, len(j.executions))
+	for i, e := range j.executions {
+		names[i] = e.rune.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// done reports whether nothing of j is left to run.
+func (j *job) done() bool {
+	return !j.finished.IsZero() || j.dryRun || j.replay
+}
+
+// elapsed returns how long j ran, or has been running.
+func (j *job) elapsed() time.Duration {
+	if j.finished.IsZero() {
+		return time.Since(j.started)
+	}
+	return j.finished.Sub(j.started)
+}
+
+// progress counts the executions of j that are over.
+func (j *job) progress() (over, total int) {
+	for _, e := range j.executions {
+		if e.status != execution.Pending && e.status != execution.Running {
+			over++
+		}
+	}
+	return over, len(j.executions)
+}
+
+// shownExecution returns the execution of j whose output is displayed.
+func (j *job) shownExecution() *runeExecution {
+	if j.shown < 0 || j.shown >= len(j.executions) {
+		return nil
+	}
+	return j.executions[j.shown]
+}
+
+// findJob returns the job with the given id.
+func (m *Model) findJob(id int) *job {
+	if m.job != nil && m.job.id == id {
+		return m.job
+	}
+	for _, j := range m.jobs {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// runningJobs counts the jobs that haven't finished.
+func (m *Model) runningJobs() int {
+	running := 0
+	for _, j := range m.jobs {
+		if !j.done() {
+			running++
+		}
+	}
+	return running
+}
+
+// attachJob shows j in the executing view.
+func (m *Model) attachJob(j *job) {
+	m.job = j
+	m.logsView = j.logsView
+	m.state = executingRune
+	m.keys = executingRuneKeys()
+	if j.replay {
+		m.keys = replayKeys()
+	}
+	m.recalculateSizes()
+	m.showExecution(j.shown)
+}
+
+// detachJob leaves the executing view, which ends watch mode. A job that is
+// still running keeps running in the background.
+func (m *Model) detachJob() {
+	m.stopWatch()
+	j := m.job
+	m.interactive = false
+	m.searching = false
+	m.job = nil
+	if j != nil && !j.done() {
+		m.StatusBar.Content = fmt.Sprintf("Job %d keeps running in the background", j.id)
+		m.StatusBar.Level = statusbar.LevelInfo
+	}
+}
+
+// abandonJob cancels the running commands of j and marks what is left of it
+// as canceled. Remaining runner messages are drained so the runners can
+// exit.
+func (m *Model) abandonJob(j *job) {
+	if j.done() {
+		return
+	}
+	for _, e := range j.executions {
+		if e.status == execution.Running || e.status == execution.Pending {
+			e.status = execution.Canceled
+		}
+		if e.cancel != nil {
+			e.cancel()
+			if e.msgChan != nil {
+				go func(ch <-chan tea.Msg) {
+					for range ch {
+					}
+				}(e.msgChan)
+			}
+		}
+	}
+	j.logsView.AddLog(log.WarnLevel, "Execution abandoned")
+	j.status = db.StatusCanceled
+	j.finished = time.Now()
+	if err := m.db.SetHistoryStatus(j.historyID, j.status); err != nil {
+		j.logsView.AddLog(log.ErrorLevel, "Failed to record execution in history", "error", err)
+	}
+	m.saveRun(j)
+}
+
+// abandonJobs abandons every job that is still running, before quitting.
+func (m *Model) abandonJobs() {
+	for _, j := range m.jobs {
+		m.abandonJob(j)
+	}
+}
+
+// cancelJob cancels the running executions of j and skips the pending ones.
+func (m *Model) cancelJob(j *job) tea.Cmd {
+	if j.done() {
+		return nil
+	}
+	m.skipPending(j)
+	for _, e := range j.executions {
+		if e.status == execution.Running {
+			m.cancelExecution(e)
+		}
+	}
+	return m.executionDone(j)
+}
+
+// updateJobs handles the messages of running jobs, whatever is on screen.
+func (m *Model) updateJobs(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case runNextCommandMsg:
+		e := m.execution(msg.id)
+		if e == nil {
+			return nil
+		}
+		if e.canceled {
+			// Canceled while waiting to retry.
+			id := e.id
+			return func() tea.Msg {
+				return executionMsg{id: id, msg: types.RuneCommandFinished{Err: context.Canceled, ExitCode: -1}}
+			}
+		}
+		if e.attempt > 0 {
+			m.logStep(
+				e,
+				log.InfoLevel,
+				"Retrying command",
+				"attempt",
+				e.attempt+1,
+				"of",
+				e.policy().Retries+1,
+			)
+		} else if e.index < len(e.steps) {
+			step := e.steps[e.index]
+			if len(step.Path) > 1 && (e.index == 0 || !slices.Equal(e.steps[e.index-1].Path, step.Path)) {
+				m.logStep(e, log.InfoLevel, "Running sub-rune", "depth", len(step.Path)-1)
+			}
+			_, dir := m.runeLocation(step.Rune)
+			m.logStep(
+				e,
+				log.InfoLevel,
+				"Executing command",
+				"cmd",
+				step.Command,
+				"dir",
+				dir,
+			)
+		}
+		return m.executeNextCommandCmd(e)
+
+	case executionMsg:
+		e := m.execution(msg.id)
+		if e == nil {
+			return nil
+		}
+		return m.updateExecution(e, msg.msg)
+
+	case countdownMsg:
+		if j := m.findJob(msg.id); j != nil && !j.done() {
+			return countdownCmd(j)
+		}
+	}
+	return nil
+}
+
+// selectedJob returns the job under the cursor of the jobs panel. The panel
+// lists the newest job first.
+func (m *Model) selectedJob() *job {
+	i := len(m.jobs) - 1 - m.cursor
+	if i < 0 || i >= len(m.jobs) {
+		return nil
+	}
+	return m.jobs[i]
+}
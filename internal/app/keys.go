@@ -25,6 +25,8 @@ type KeyMap struct {
 	New           key.Binding
 	ClearFilter   key.Binding
 	QueueRune     key.Binding
+	ParallelQueue key.Binding
+	DryRun        key.Binding
 	NextField     key.Binding
 	AddCommand    key.Binding
 	RemoveCommand key.Binding
@@ -33,6 +35,14 @@ type KeyMap struct {
 	submit        key.Binding
 	Cancel        key.Binding
 	Yank          key.Binding
+	Interactive   key.Binding
+	NextOutput    key.Binding
+	PrevOutput    key.Binding
+	Search        key.Binding
+	NextMatch     key.Binding
+	PrevMatch     key.Binding
+	View          key.Binding
+	Watch         key.Binding
 }
 
 func viewPortKeys() KeyMap {
@@ -64,12 +74,17 @@ func mainListKeys() KeyMap {
 
 func viewingRunesKeys() KeyMap {
 	return KeyMap{
-		Up:          key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
-		Down:        key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
-		Enter:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run")),
-		Edit:        key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "edit")),
-		Delete:      key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "delete")),
-		QueueRune:   key.NewBinding(key.WithKeys("ctrl+q"), key.WithHelp("ctrl+q", "queue rune")),
+		Up:        key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:      key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Enter:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run")),
+		Edit:      key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "edit")),
+		Delete:    key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "delete")),
+		QueueRune: key.NewBinding(key.WithKeys("ctrl+q"), key.WithHelp("ctrl+q", "queue rune")),
+		ParallelQueue: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "toggle parallel queue"),
+		),
+		DryRun:      key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "dry run")),
 		SwitchFocus: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "Toggle focus")),
 		Esc:         key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
 		GlobalQuit:  key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "quit")),
@@ -134,11 +149,41 @@ func executingRuneKeys() KeyMap {
 		Down:        key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "scroll down")),
 		SwitchFocus: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "switch focus")),
 		Enter:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "back")),
-		Esc:         key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+		Esc:         key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back (keeps running)")),
 		GlobalQuit:  key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "quit")),
 		Help:        key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
-		Cancel:      key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "cancel command")),
+		Cancel:      key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "cancel command (again to kill)")),
 		Yank:        key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "yank logs")),
+		Interactive: key.NewBinding(
+			key.WithKeys("ctrl+]"),
+			key.WithHelp("ctrl+]", "interactive mode"),
+		),
+		NextOutput: key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "next output")),
+		PrevOutput: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "previous output")),
+		Search:     key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+		NextMatch:  key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next match")),
+		PrevMatch:  key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "previous match")),
+		Watch:      key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "watch mode")),
+	}
+}
+
+// replayKeys is used to look at a stored execution from the history.
+func replayKeys() KeyMap {
+	k := executingRuneKeys()
+	k.Cancel.SetEnabled(false)
+	k.Interactive.SetEnabled(false)
+	k.Watch.SetEnabled(false)
+	return k
+}
+
+// interactiveKeys is used while keystrokes are forwarded to the running
+// command; only the toggle back to navigation is handled by Catalyst.
+func interactiveKeys() KeyMap {
+	return KeyMap{
+		Interactive: key.NewBinding(
+			key.WithKeys("ctrl+]"),
+			key.WithHelp("ctrl+]", "leave interactive mode"),
+		),
 	}
 }
 
@@ -174,6 +219,9 @@ func (k KeyMap) ShortHelp() []key.Binding {
 	if k.Delete.Enabled() {
 		b = append(b, k.Delete)
 	}
+	if k.Interactive.Enabled() {
+		b = append(b, k.Interactive)
+	}
 	if k.Quit.Enabled() {
 		b = append(b, k.Quit)
 	}
@@ -216,6 +264,30 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	if k.QueueRune.Enabled() {
 		b = append(b, k.QueueRune)
 	}
+	if k.ParallelQueue.Enabled() {
+		b = append(b, k.ParallelQueue)
+	}
+	if k.DryRun.Enabled() {
+		b = append(b, k.DryRun)
+	}
+	if k.View.Enabled() {
+		b = append(b, k.View)
+	}
+	if k.Search.Enabled() {
+		b = append(b, k.Search)
+	}
+	if k.NextMatch.Enabled() {
+		b = append(b, k.NextMatch)
+	}
+	if k.PrevMatch.Enabled() {
+		b = append(b, k.PrevMatch)
+	}
+	if k.PrevOutput.Enabled() {
+		b = append(b, k.PrevOutput)
+	}
+	if k.NextOutput.Enabled() {
+		b = append(b, k.NextOutput)
+	}
 	if k.New.Enabled() {
 		b = append(b, k.New)
 	}
@@ -228,6 +300,12 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	if k.Yank.Enabled() {
 		b = append(b, k.Yank)
 	}
+	if k.Watch.Enabled() {
+		b = append(b, k.Watch)
+	}
+	if k.Interactive.Enabled() {
+		b = append(b, k.Interactive)
+	}
 	if k.ClearFilter.Enabled() {
 		b = append(b, k.ClearFilter)
 	}
@@ -251,6 +329,19 @@ func viewingHistoryKeys() KeyMap {
 		Up:         key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
 		Down:       key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
 		Enter:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run")),
+		View:       key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "view output")),
+		Esc:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+		GlobalQuit: key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "quit")),
+		Help:       key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+	}
+}
+
+func viewingJobsKeys() KeyMap {
+	return KeyMap{
+		Up:         key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:       key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Enter:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "attach")),
+		Cancel:     key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "cancel job")),
 		Esc:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
 		GlobalQuit: key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "quit")),
 		Help:       key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
@@ -1,11 +1,9 @@
 package app
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"catalyst/internal/app/components/core"
@@ -14,6 +12,7 @@ import (
 	"catalyst/internal/config"
 	"catalyst/internal/db"
 	"catalyst/internal/local"
+	"catalyst/internal/spellbook"
 	"catalyst/internal/ssh"
 	"catalyst/internal/types"
 	"catalyst/internal/utils"
@@ -24,6 +23,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
 	"path/filepath"
+	"slices"
 	"sort"
 )
 
@@ -42,6 +42,8 @@ const (
 	creatingLoeg
 	editingRune
 	showingHistory
+	promptingParameters
+	showingJobs
 	errState
 )
 
@@ -59,7 +61,7 @@ const (
 type (
 	gotSpellbookMsg   struct{ spellbook Spellbook }
 	runeCreatedMsg    struct{}
-	runNextCommandMsg struct{}
+	runNextCommandMsg struct{ id int }
 	gotLoegsMsg       struct{ loegs map[string]string }
 	loegSetMsg        struct{}
 	loegRemovedMsg    struct{}
@@ -112,7 +114,6 @@ type Model struct {
 	history               []db.HistoryEntry      // For the history view
 	inputs                []core.CustomTextInput // For the "Create Rune" form
 	focusIndex            int
-	output                string // To store output from executed runes
 	err                   error
 	lockScreen            *core.LockScreenModel
 	logsView              *core.LogsViewModel
@@ -125,15 +126,68 @@ type Model struct {
 	Version               string
 	SpellbookString       string
 
-	// For sequential command execution
-	commandsToExecute   []string
-	currentCommandIndex int
-	currentCancelFunc   context.CancelFunc
-	executingRuneName   string
-	msgChan             chan tea.Msg
-	executionQueue      []types.Rune
-	executionQueueIndex int
-	systemCommands      []string
+	// For command execution
+	jobs            []*job // Jobs that run commands, in the order they started
+	job             *job   // Job shown in the executing view
+	nextJobID       int
+	nextExecutionID int  // Tells stale runner messages apart
+	parallelQueue   bool // Run queued runes in parallel
+	concurrency     int  // Runes run at once in parallel mode
+	cfg             *config.Config
+	commandTimeout  time.Duration
+	pendingRun      *pendingRun // Run waiting for its parameters
+	dataDir         string      // Where execution output is stored
+	searching       bool        // The search input is open
+	searchInput     core.CustomTextInput
+	executionQueue  []types.Rune
+	systemCommands  []string
+	interactive     bool        // Forward keystrokes to the shown execution
+	watch           *watchState // Watch mode of the executing view
+	nextWatchID     int
+
+	// For scheduled runes
+	scheduleStart time.Time            // When schedules started being honored
+	scheduleLast  map[string]time.Time // Last fire of each rune, as of the last check
+	scheduleErrs  map[string]error     // Failed history lookups of the last check
+}
+
+// execTotals accumulates the resource usage of finished commands.
+type execTotals struct {
+	commands   int
+	duration   time.Duration
+	userTime   time.Duration
+	systemTime time.Duration
+}
+
+func (t *execTotals) add(msg types.RuneCommandFinished) {
+	t.commands++
+	t.duration += msg.Duration
+	t.userTime += msg.UserTime
+	t.systemTime += msg.SystemTime
+}
+
+// keyvals returns the totals as log key-value pairs.
+func (t execTotals) keyvals() []any {
+	return []any{
+		"commands", t.commands,
+		"wall", t.duration.Round(time.Millisecond),
+		"user", t.userTime.Round(time.Millisecond),
+		"sys", t.systemTime.Round(time.Millisecond),
+	}
+}
+
+// commandResultKeyvals returns the exit status and timings of a finished
+// command as log key-value pairs.
+func commandResultKeyvals(msg types.RuneCommandFinished) []any {
+	keyvals := []any{"exit", msg.ExitCode}
+	if msg.Signal != nil {
+		keyvals = append(keyvals, "signal", msg.Signal)
+	}
+	return append(keyvals,
+		"wall", msg.Duration.Round(time.Millisecond),
+		"user", msg.UserTime.Round(time.Millisecond),
+		"sys", msg.SystemTime.Round(time.Millisecond),
+	)
 }
 
 // NewModel creates a new application model.
@@ -153,16 +207,21 @@ func NewModel(cfg *config.Config, db *db.Database, version string) Model {
 	)
 	statusbar.ShowSpinner = true
 
+	runner := local.NewRunner(cfg.ShellCommand())
+	runner.Foreground = theme.FgBase
+	runner.Background = theme.BgBase
+	runner.Version = version
+
 	m := Model{
 		help:              help,
 		keys:              initialsKeys,
 		sshClient:         ssh.NewClient(cfg.RuneCraftHost),
-		localRunner:       local.NewRunner(),
+		localRunner:       runner,
 		db:                db,
 		state:             checkingSpellbook,
 		pwd:               pwd,
 		menuItems:         core.NewMainMenu(*theme),
-		runesList:         core.NewRunesList(*theme, []types.Rune{}),
+		runesList:         core.NewRunesList(*theme, []types.Rune{}, cfg.ShellCommand()),
 		inputs:            make([]core.CustomTextInput, 3), // name, desc, cmds
 		focusIndex:        0,
 		Theme:             theme,
@@ -172,6 +231,11 @@ func NewModel(cfg *config.Config, db *db.Database, version string) Model {
 		viewportSpellBook: viewport.New(),
 		formViewport:      viewport.New(),
 		executingViewport: viewport.New(),
+		concurrency:       cfg.QueueConcurrency(),
+		cfg:               cfg,
+		dataDir:           dataDir(),
+		searchInput:       newSearchInput(*theme),
+		commandTimeout:    cfg.CommandTimeout,
 		systemCommands:    loadSystemCommands(),
 	}
 
@@ -197,6 +261,24 @@ func NewModel(cfg *config.Config, db *db.Database, version string) Model {
 	return m
 }
 
+// dataDir returns where execution output is stored, or an empty string if
+// it can't be stored.
+func dataDir() string {
+	dir, err := db.DataDir()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+func newSearchInput(theme styles.Theme) core.CustomTextInput {
+	t := core.NewTextInput("Search", theme)
+	t.Model.Prompt = "/"
+	t.Model.Placeholder = "search"
+	t.Model.ShowSuggestions = false
+	return t
+}
+
 // loadSystemCommands scans the PATH environment variable to find all available
 // executable commands.
 func loadSystemCommands() []string {
@@ -281,6 +363,26 @@ func (m *Model) recalculateSizes(options ...RecalcOption) {
 		}
 		m.executingViewport.SetWidth(m.width * 2 / 3)
 		m.executingViewport.SetHeight(availableHeightForMainContent)
+		// Background jobs are resized too, ready to be attached again.
+		width, height := m.executingViewport.Width(), m.executingViewport.Height()
+		jobs := m.jobs
+		if m.job != nil && !slices.Contains(jobs, m.job) {
+			jobs = append(slices.Clip(jobs), m.job)
+		}
+		for _, j := range jobs {
+			for _, e := range j.executions {
+				if w, h := e.screen.Size(); w == width && h == height {
+					continue
+				}
+				e.screen.Resize(width, height)
+				if e.terminal != nil {
+					_ = e.terminal.Resize(width, height)
+				}
+				if e == m.shown() {
+					m.executingViewport.SetContent(e.screen.Render())
+				}
+			}
+		}
 	default:
 		m.viewportSpellBook.SetWidth(m.width * 3 / 4)
 		m.viewportSpellBook.SetHeight(availableHeightForMainContent)
@@ -292,35 +394,24 @@ func (m *Model) recalculateSizes(options ...RecalcOption) {
 
 // getSpellbookContentCmd fetches the entire spellbook content.
 func (m *Model) getSpellbookContentCmd() tea.Msg {
-	cmd := fmt.Sprintf("get-spellbook-content %q", m.pwd)
-	jsonStr, err := m.sshClient.Command(cmd)
+	sb, err := spellbook.Fetch(m.sshClient, m.pwd)
+	if errors.Is(err, spellbook.ErrInvalid) {
+		return errMsg{err}
+	}
 	if err != nil {
 		// A failure here might mean the spellbook doesn't exist.
 		return errMsg{err: nil} // Signal to create it.
 	}
-
-	var sb Spellbook
-	if err := json.Unmarshal([]byte(jsonStr), &sb); err != nil {
-		return errMsg{err}
-	}
-	return gotSpellbookMsg{spellbook: sb}
+	return gotSpellbookMsg{spellbook: *sb}
 }
 
 // createSpellbookCmd now also fetches the content after creation.
 func (m *Model) createSpellbookCmd() tea.Msg {
-	cmd := fmt.Sprintf("create-spellbook %q", m.pwd)
-	jsonStr, err := m.sshClient.Command(cmd)
+	sb, err := spellbook.Create(m.sshClient, m.pwd)
 	if err != nil {
 		return errMsg{err}
 	}
-
-	var sb Spellbook
-	if err := json.Unmarshal([]byte(jsonStr), &sb); err != nil {
-		// Even if creation is successful, we might fail to parse.
-		// Let's return the raw JSON in this edge case.
-		return errMsg{fmt.Errorf("failed to parse created spellbook: %w", err)}
-	}
-	return gotSpellbookMsg{spellbook: sb}
+	return gotSpellbookMsg{spellbook: *sb}
 }
 
 // All CRUD operations will now just trigger a full refresh of the spellbook.
@@ -328,24 +419,12 @@ func (m *Model) createSpellbookCmd() tea.Msg {
 
 // createRuneCmd sends the command to create a new rune.
 func (m *Model) createRuneCmd() tea.Msg {
-	// ... (build cmdsStr as before)
-	name := m.inputs[0].Value()
-	desc := m.inputs[1].Value()
-
-	var cmds []string
-	for i := 2; i < len(m.inputs); i++ {
-		if val := m.inputs[i].Value(); val != "" {
-			cmds = append(cmds, val)
-		}
-	}
-	cmdsStr := strings.Join(cmds, ";")
-
-	if name == "" || desc == "" || cmdsStr == "" {
-		return errMsg{fmt.Errorf("name, description, and at least one command are required")}
+	r := types.Rune{
+		Name:        m.inputs[0].Value(),
+		Description: m.inputs[1].Value(),
+		Commands:    m.formCommands(),
 	}
-
-	cmd := fmt.Sprintf("create-rune %q -name %q -desc %q -cmds %q", m.pwd, name, desc, cmdsStr)
-	_, err := m.sshClient.Command(cmd)
+	err := spellbook.CreateRune(m.sshClient, m.pwd, r)
 	if err != nil {
 		return errMsg{err}
 	}
@@ -357,55 +436,6 @@ func (m *Model) createRuneCmd() tea.Msg {
 	)()
 }
 
-// executeSpecificRuneCmd sets up the model for sequential command execution.
-func (m *Model) executeSpecificRuneCmd(r types.Rune, saveHistory bool) tea.Cmd {
-	return func() tea.Msg {
-		if saveHistory {
-			if err := m.db.AddHistoryEntry([]string{r.Name}, m.spellbook.Name); err != nil {
-				return errMsg{err}
-			}
-		}
-		m.commandsToExecute = r.Commands
-		m.currentCommandIndex = 0
-		return runNextCommandMsg{}
-	}
-}
-
-// executeNextCommandCmd starts a command and returns a command that listens for its output.
-func (m *Model) executeNextCommandCmd() tea.Cmd {
-	if m.currentCommandIndex >= len(m.commandsToExecute) {
-		return func() tea.Msg { return types.RuneCommandFinished{Err: nil} }
-	}
-
-	command := m.commandsToExecute[m.currentCommandIndex]
-	ctx, cancel := context.WithCancel(context.Background())
-	m.currentCancelFunc = cancel
-
-	m.msgChan = make(chan tea.Msg)
-	go func() {
-		defer close(m.msgChan)
-		m.localRunner.ExecuteCommand(ctx, command, m.msgChan)
-	}()
-
-	return waitForOutput(m.msgChan)
-}
-
-// waitForOutput is a tea.Cmd that waits for the next message from a channel.
-func waitForOutput(ch <-chan tea.Msg) tea.Cmd {
-	return func() tea.Msg {
-		// This will block until a message is available.
-		// Bubble Tea will handle this in its runtime.
-		msg, ok := <-ch
-		if !ok {
-			// Channel was closed, which means the command is done.
-			// We shouldn't get here if the runner sends RuneCommandFinished,
-			// but as a safeguard:
-			return types.RuneCommandFinished{Err: nil}
-		}
-		return msg
-	}
-}
-
 // getHistoryCmd retrieves the execution history from the database.
 func (m *Model) getHistoryCmd() tea.Msg {
 	history, err := m.db.GetHistory()
@@ -422,13 +452,7 @@ func (m *Model) setLoegCmd() tea.Msg {
 	// ... (build command as before)
 	key := m.inputs[0].Value()
 	val := m.inputs[1].Value()
-	if key == "" || val == "" {
-		return errMsg{fmt.Errorf("key and value are required")}
-	}
-
-	arg := fmt.Sprintf("%s=\"%s\"", key, val)
-	cmd := fmt.Sprintf("loeg set %q %s", m.pwd, arg)
-	_, err := m.sshClient.Command(cmd)
+	err := spellbook.SetLoeg(m.sshClient, m.pwd, key, val)
 	if err != nil {
 		return errMsg{err}
 	}
@@ -446,9 +470,7 @@ func (m *Model) removeLoegCmd() tea.Msg {
 		return errMsg{fmt.Errorf("invalid loeg selection")}
 	}
 	key := m.loegKeys[m.cursor]
-	// ... (build command as before)
-	cmd := fmt.Sprintf("loeg rm %q %s", m.pwd, key)
-	_, err := m.sshClient.Command(cmd)
+	err := spellbook.RemoveLoeg(m.sshClient, m.pwd, key)
 	if err != nil {
 		return errMsg{err}
 	}
@@ -469,38 +491,26 @@ func (m *Model) updateRuneCmd() tea.Msg {
 	selectedRune := selectedItem.Rune
 	originalName := selectedRune.Name
 
-	newName := m.inputs[0].Value()
-	newDesc := m.inputs[1].Value()
-
-	var newCmds []string
-	for i := 2; i < len(m.inputs); i++ {
-		if val := m.inputs[i].Value(); val != "" {
-			newCmds = append(newCmds, val)
-		}
-	}
-	newCmdsStr := strings.Join(newCmds, ";")
-
-	// Build the command dynamically
-	var parts []string
-	parts = append(parts, "update-rune", fmt.Sprintf("%q", m.pwd), fmt.Sprintf("%q", originalName))
-
-	if newName != "" && newName != originalName {
-		parts = append(parts, "-name", fmt.Sprintf("%q", newName))
+	// The form edits the name, description and commands; the other fields
+	// stay as they are.
+	updated := selectedRune
+	if newName := m.inputs[0].Value(); newName != "" {
+		updated.Name = newName
 	}
-	if newDesc != "" && newDesc != selectedRune.Description {
-		parts = append(parts, "-desc", fmt.Sprintf("%q", newDesc))
+	if newDesc := m.inputs[1].Value(); newDesc != "" {
+		updated.Description = newDesc
 	}
-	if newCmdsStr != "" && newCmdsStr != strings.Join(selectedRune.Commands, ";") {
-		parts = append(parts, "-cmds", fmt.Sprintf("%q", newCmdsStr))
+	if newCmds := m.formCommands(); len(newCmds) > 0 {
+		updated.Commands = newCmds
 	}
 
 	// If no changes were made, don't run the command
-	if len(parts) <= 3 {
+	if updated.Name == originalName && updated.Description == selectedRune.Description &&
+		slices.EqualFunc(updated.Commands, selectedRune.Commands, types.Command.Equal) {
 		return noChangesMsg{}
 	}
 
-	cmd := strings.Join(parts, " ")
-	_, err := m.sshClient.Command(cmd)
+	err := spellbook.UpdateRune(m.sshClient, m.pwd, selectedRune, updated)
 	if err != nil {
 		return errMsg{err}
 	}
@@ -512,15 +522,25 @@ func (m *Model) updateRuneCmd() tea.Msg {
 	)()
 }
 
+// formCommands returns the commands entered in the rune form, each with the
+// failure policy it had.
+func (m *Model) formCommands() []types.Command {
+	var commands []types.Command
+	for i := 2; i < len(m.inputs); i++ {
+		if val := m.inputs[i].Value(); val != "" {
+			commands = append(commands, types.Command{Run: val, Policy: m.inputs[i].Policy})
+		}
+	}
+	return commands
+}
+
 // deleteRuneCmd sends the command to delete a rune.
 func (m *Model) deleteRuneCmd() tea.Msg {
 	selectedItem, ok := m.runesList.SelectedItem().(core.RuneItem)
 	if !ok {
 		return errMsg{fmt.Errorf("invalid rune selection for delete")}
 	}
-	runeName := selectedItem.Rune.Name
-	cmd := fmt.Sprintf("delete-rune %q %q", m.pwd, runeName)
-	_, err := m.sshClient.Command(cmd)
+	err := spellbook.DeleteRune(m.sshClient, m.pwd, selectedItem.Rune.Name)
 	if err != nil {
 		return errMsg{err}
 	}
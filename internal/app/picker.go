@@ -0,0 +1,108 @@
+package app
+
+import (
+	"strings"
+
+	"catalyst/internal/app/components/core"
+	"catalyst/internal/app/styles"
+	"catalyst/internal/types"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/list"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// pickerHeight is how many lines the runes list of the picker takes.
+const pickerHeight = 10
+
+// Picker is a compact runes list, drawn inline in the terminal, that fuzzy
+// filters the runes as the user types and returns the one picked.
+type Picker struct {
+	list   list.Model
+	theme  *styles.Theme
+	keys   pickerKeys
+	picked *types.Rune
+	done   bool
+}
+
+type pickerKeys struct {
+	pick, cancel, up, down key.Binding
+}
+
+// NewPicker creates a picker for runes. shell is the default interpreter of
+// the runes.
+func NewPicker(runes []types.Rune, shell string) *Picker {
+	theme := styles.NewCharmtoneTheme()
+	l := core.NewRunesList(*theme, runes, shell)
+	l.SetShowPagination(false)
+	l.SetFilterState(list.Filtering)
+	return &Picker{
+		list:  l,
+		theme: theme,
+		keys: pickerKeys{
+			pick:   key.NewBinding(key.WithKeys("enter")),
+			cancel: key.NewBinding(key.WithKeys("esc", "ctrl+c", "ctrl+g")),
+			up:     key.NewBinding(key.WithKeys("up", "ctrl+p")),
+			down:   key.NewBinding(key.WithKeys("down", "ctrl+n")),
+		},
+	}
+}
+
+// Picked returns the rune the user picked, if any.
+func (p *Picker) Picked() (types.Rune, bool) {
+	if p.picked == nil {
+		return types.Rune{}, false
+	}
+	return *p.picked, true
+}
+
+func (p *Picker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *Picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.list.SetSize(msg.Width, pickerHeight)
+		return p, nil
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keys.pick):
+			if item, ok := p.list.SelectedItem().(core.RuneItem); ok {
+				p.picked = &item.Rune
+				p.done = true
+				return p, tea.Quit
+			}
+			return p, nil
+		case key.Matches(msg, p.keys.cancel):
+			p.done = true
+			return p, tea.Quit
+		case key.Matches(msg, p.keys.up):
+			p.list.CursorUp()
+			return p, nil
+		case key.Matches(msg, p.keys.down):
+			p.list.CursorDown()
+			return p, nil
+		}
+	}
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	return p, cmd
+}
+
+func (p *Picker) View() string {
+	if p.done {
+		// Leave nothing behind in the terminal.
+		return ""
+	}
+	muted := p.theme.AppStyles().Base.Foreground(p.theme.FgSubtle)
+	var b strings.Builder
+	b.WriteString(p.list.View())
+	b.WriteString("\n")
+	if item, ok := p.list.SelectedItem().(core.RuneItem); ok && item.Rune.Description != "" {
+		b.WriteString(muted.Render(item.Rune.Description))
+		b.WriteString("\n")
+	}
+	b.WriteString(muted.Render("enter insert commands • esc cancel"))
+	return b.String()
+}
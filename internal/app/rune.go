@@ -1 +1,37 @@
 package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"catalyst/internal/types"
+)
+
+// needsTree draws the runes r needs as a tree.
+func (m *Model) needsTree(r types.Rune) string {
+	var b strings.Builder
+	b.WriteString(r.Name + "\n")
+	var draw func(r types.Rune, prefix string, path []string)
+	draw = func(r types.Rune, prefix string, path []string) {
+		path = append(slices.Clip(path), r.Name)
+		for i, name := range r.Needs {
+			branch, indent := "├── ", "│   "
+			if i == len(r.Needs)-1 {
+				branch, indent = "└── ", "    "
+			}
+			need, ok := m.spellbook.Rune(name)
+			switch {
+			case !ok:
+				fmt.Fprintf(&b, "%s%s%s (unknown)\n", prefix, branch, name)
+			case slices.Contains(path, name):
+				fmt.Fprintf(&b, "%s%s%s (cycle)\n", prefix, branch, name)
+			default:
+				fmt.Fprintf(&b, "%s%s%s\n", prefix, branch, name)
+				draw(need, prefix+indent, path)
+			}
+		}
+	}
+	draw(r, "", nil)
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"catalyst/internal/app/components/statusbar"
+	"catalyst/internal/db"
+	"catalyst/internal/schedule"
+	"catalyst/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// scheduleInterval is how often the schedules of the runes are checked.
+const scheduleInterval = 30 * time.Second
+
+// scheduleTickMsg checks whether scheduled runes are due.
+type scheduleTickMsg struct{}
+
+// scheduleTickCmd checks the schedules again after scheduleInterval.
+func scheduleTickCmd() tea.Cmd {
+	return tea.Tick(scheduleInterval, func(time.Time) tea.Msg { return scheduleTickMsg{} })
+}
+
+// startSchedules starts honoring the schedules of the runes, once. The
+// first check happens right away, so the rune details know the last runs.
+func (m *Model) startSchedules() tea.Cmd {
+	if !m.scheduleStart.IsZero() {
+		return nil
+	}
+	m.scheduleStart = time.Now()
+	m.scheduleLast = make(map[string]time.Time)
+	m.scheduleErrs = make(map[string]error)
+	return func() tea.Msg { return scheduleTickMsg{} }
+}
+
+// checkLastFire looks up when the schedule of r last fired, in this session
+// or recorded in the history, if ever, and remembers it for the rune
+// details.
+func (m *Model) checkLastFire(r types.Rune) (time.Time, error) {
+	last, _, err := m.db.LastScheduledRun(m.spellbook.Name, r.Name)
+	if err != nil {
+		m.scheduleErrs[r.Name] = err
+		return time.Time{}, err
+	}
+	delete(m.scheduleErrs, r.Name)
+	if fired := m.scheduleLast[r.Name]; fired.After(last) {
+		last = fired
+	}
+	m.scheduleLast[r.Name] = last
+	return last, nil
+}
+
+// nextFire returns when the schedule of r fires next, or the zero time if it
+// never does. Runs missed before Catalyst started are not caught up.
+func (m *Model) nextFire(s schedule.Schedule, last time.Time) time.Time {
+	since := m.scheduleStart
+	if since.IsZero() {
+		since = time.Now()
+	}
+	return schedule.NextRun(s, last, since)
+}
+
+// runSchedules starts a background job for each scheduled rune that is due
+// and isn't running already. Scheduled runs use the default values of the
+// parameters.
+func (m *Model) runSchedules() tea.Cmd {
+	cmds := []tea.Cmd{scheduleTickCmd()}
+	if m.spellbook == nil {
+		return tea.Batch(cmds...)
+	}
+	now := time.Now()
+	for _, r := range m.spellbook.Runes {
+		if r.Schedule == "" {
+			continue
+		}
+		s, err := schedule.Parse(r.Schedule)
+		if err != nil {
+			continue
+		}
+		last, err := m.checkLastFire(r)
+		if err != nil || m.scheduledRunning(r.Name) {
+			continue
+		}
+		if next := m.nextFire(s, last); next.IsZero() || now.Before(next) {
+			continue
+		}
+		m.scheduleLast[r.Name] = now
+		cmds = append(cmds, m.startScheduled(r))
+	}
+	return tea.Batch(cmds...)
+}
+
+// startScheduled starts r, preceded by the runes it needs, in a background
+// job.
+func (m *Model) startScheduled(r types.Rune) tea.Cmd {
+	runes, err := m.spellbook.Plan([]types.Rune{r})
+	var params map[string]string
+	if err == nil {
+		params, err = types.ParameterValues(types.RuneParameters(m.spellbook.WithSubRunes(runes)), nil)
+	}
+	var j *job
+	if err == nil {
+		j, err = m.startJob(runes, params, db.TriggerScheduled)
+	}
+	if err != nil {
+		m.StatusBar.Content = fmt.Sprintf("Scheduled rune %s didn't start: %v", r.Name, err)
+		m.StatusBar.Level = statusbar.LevelError
+		return clearStatusCmd()
+	}
+	m.StatusBar.Content = fmt.Sprintf("Scheduled rune %s started as job %d", r.Name, j.id)
+	m.StatusBar.Level = statusbar.LevelInfo
+	return m.runJob(j)
+}
+
+// scheduledRunning reports whether a scheduled job of the rune named name is
+// still running.
+func (m *Model) scheduledRunning(name string) bool {
+	for _, j := range m.jobs {
+		if j.done() || j.trigger != db.TriggerScheduled || len(j.executions) == 0 {
+			continue
+		}
+		if j.executions[len(j.executions)-1].rune.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleDetail describes the schedule of r for the rune detail pane, as
+// of the last check of the schedules.
+func (m *Model) scheduleDetail(r types.Rune) string {
+	detail := fmt.Sprintf("`%s`\n\n", r.Schedule)
+	s, err := schedule.Parse(r.Schedule)
+	if err != nil {
+		return detail + fmt.Sprintf("> %v\n\n", err)
+	}
+	if err := m.scheduleErrs[r.Name]; err != nil {
+		return detail + fmt.Sprintf("> %v\n\n", err)
+	}
+	last, checked := m.scheduleLast[r.Name]
+	if !checked {
+		return detail + "- Next: not checked yet\n\n"
+	}
+	next := m.nextFire(s, last)
+	switch {
+	case m.scheduledRunning(r.Name):
+		detail += "- Next: running now\n"
+	case next.IsZero():
+		detail += "- Next: never\n"
+	case !time.Now().Before(next):
+		detail += "- Next: due\n"
+	default:
+		detail += fmt.Sprintf("- Next: %s\n", next.Format("2006-01-02 15:04"))
+	}
+	if last.IsZero() {
+		detail += "- Last: never\n\n"
+	} else {
+		detail += fmt.Sprintf("- Last: %s\n\n", last.Format("2006-01-02 15:04:05"))
+	}
+	return detail
+}
@@ -20,6 +20,7 @@ func NewCharmtoneTheme() *Theme {
 		FillTextLine:     charmtone.Sardine,
 		FocusableElement: charmtone.Mustard,
 
+		BgBase:    charmtone.Pepper,
 		BgOverlay: charmtone.Iron,
 		Input:     charmtone.Sardine,
 		Output:    charmtone.Guppy,
@@ -26,6 +26,7 @@ type Theme struct {
 	FillTextLine     color.Color
 	FocusableElement color.Color
 
+	BgBase    color.Color
 	BgOverlay color.Color
 	Input     color.Color
 	Output    color.Color
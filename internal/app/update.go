@@ -2,14 +2,15 @@ package app
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"time"
 
+	"catalyst/internal/execution"
+	"catalyst/internal/local"
 	"catalyst/internal/types"
 
-	"github.com/charmbracelet/log/v2"
-
 	"github.com/charmbracelet/glamour"
 
 	"catalyst/internal/app/components/core"
@@ -39,6 +40,20 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case HideLockScreenMsg:
 		m.lockScreen = nil
 		return m, m.getSpellbookContentCmd // This is the new centralized refresh point
+
+	// Jobs, schedules and watch mode go on under popups and lock screens: a
+	// lost message would leave an execution waiting, or stop the ticks, for
+	// good.
+	case runNextCommandMsg, executionMsg, countdownMsg:
+		return m, tea.Batch(append(cmds, m.updateJobs(msg))...)
+	case scheduleTickMsg:
+		// Only runSchedules asks for the next tick.
+		return m, tea.Batch(append(cmds, m.runSchedules())...)
+	case watchTickMsg:
+		return m, tea.Batch(append(cmds, m.scanWatchCmd(msg.id))...)
+	case watchScanMsg:
+		// Only updateWatch asks for the next tick.
+		return m, tea.Batch(append(cmds, m.updateWatch(msg))...)
 	}
 
 	// If a popup is active, it captures all input and blocks other components.
@@ -92,7 +107,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.StatusBar.AppWith = m.width
-		m.recalculateSizes()
+		if m.state == executingRune {
+			// Size the output like View does so the command's terminal is
+			// resized once, to its final size.
+			m.recalculateSizes(WithExtraContent(m.executingBordersHeight()))
+		} else {
+			m.recalculateSizes()
+		}
 		if m.lockScreen != nil {
 			m.lockScreen.Resize(m.width, m.availableHeight)
 		}
@@ -106,6 +127,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 		case key.Matches(msg, m.keys.GlobalQuit):
+			m.abandonJobs()
 			return m, tea.Quit
 		}
 	case clearStatusMsg:
@@ -136,6 +158,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		_, stateCmd = updateEditingRune(msg, m)
 	case showingHistory:
 		_, stateCmd = updateShowingHistory(msg, m)
+	case promptingParameters:
+		_, stateCmd = updatePromptingParameters(msg, m)
+	case showingJobs:
+		_, stateCmd = updateShowingJobs(msg, m)
 	default:
 		_, stateCmd = updateInitial(msg, m)
 	}
@@ -200,6 +226,10 @@ func (m *Model) getDefaultStatusBarContent() string {
 		return "Viewing Loegs"
 	case creatingLoeg:
 		return "Creating a new Loeg"
+	case promptingParameters:
+		return "Fill in the parameters"
+	case showingJobs:
+		return "Viewing Jobs"
 	default:
 		return "Ready"
 	}
@@ -229,7 +259,7 @@ func updateInitial(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 		m.StatusBar.StopSpinner()
 		m.focusedElement = listElement
 		utils.ResetListFilterState(&m.menuItems)
-		return m, continueToReadyCmd()
+		return m, tea.Batch(continueToReadyCmd(), m.startSchedules())
 	case errMsg:
 		finalMsg := "An error occurred"
 		if msg.err != nil {
@@ -276,6 +306,7 @@ func updateReady(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 			m.changeFocusedElement()
 			return m, nil
 		case key.Matches(msg, m.keys.GlobalQuit):
+			m.abandonJobs()
 			return m, tea.Quit
 		}
 	case gotSpellbookMsg: // Centralized update path
@@ -339,7 +370,7 @@ func updateReady(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 
 					// Initialize viewport with the first rune's details
 					if len(m.spellbook.Runes) > 0 {
-						md := formatRuneDetail(m.spellbook.Runes[0])
+						md := m.formatRuneDetail(m.spellbook.Runes[0])
 						rendered, _ := glamour.Render(md, "dark")
 						m.viewportSpellBook.SetContent(rendered)
 					}
@@ -401,6 +432,12 @@ func updateReady(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 					m.state = showingHistory
 					m.StatusBar.Content = "Viewing History"
 					return m, m.getHistoryCmd
+				case 4: // View Jobs
+					m.state = showingJobs
+					m.keys = viewingJobsKeys()
+					m.cursor = 0
+					m.StatusBar.Content = "Viewing Jobs"
+					return m, nil
 
 				}
 			}
@@ -456,44 +493,32 @@ func updateShowingRunes(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 			m.StatusBar.Content = m.SpellbookString
 			return m, nil
 
-		case key.Matches(msg, m.keys.Enter):
-			m.previousState = showingRunes
-			m.state = executingRune
-			m.keys = executingRuneKeys()
-			m.executingViewport.SetContent("")
-			m.output = ""
-
-			if len(m.executionQueue) > 0 {
+		case key.Matches(msg, m.keys.Enter), key.Matches(msg, m.keys.DryRun):
+			runesToExecute := m.executionQueue
+			if len(runesToExecute) > 0 {
 				m.StatusBar.Content = "Executing rune queue..."
-				m.executionQueueIndex = 0
-				m.logsView = core.NewLogsView(m.width/3, m.availableHeight, m.Theme)
-				m.focusedElement = logsViewportElement // Set initial focus
-				m.recalculateSizes()
-
-				// Save the entire queue to history
-				var runeIDs []string
-				for _, r := range m.executionQueue {
-					runeIDs = append(runeIDs, r.Name)
-				}
-				if err := m.db.AddHistoryEntry(runeIDs, m.spellbook.Name); err != nil {
-					return m, func() tea.Msg { return errMsg{err} }
-				}
-
-				return m, tea.Batch(m.StatusBar.StartSpinner(), m.executeQueuedRuneCmd())
 			} else {
 				selectedItem, ok := m.runesList.SelectedItem().(core.RuneItem)
 				if !ok {
 					return m, nil
 				}
-
+				runesToExecute = []types.Rune{selectedItem.Rune}
 				m.StatusBar.Content = "Executing rune..."
-				m.executingRuneName = selectedItem.Rune.Name // Store the name
-				m.logsView = core.NewLogsView(m.width/3, m.availableHeight, m.Theme)
-				m.focusedElement = logsViewportElement // Set initial focus
-				m.recalculateSizes()
-				return m, tea.Batch(m.StatusBar.StartSpinner(), m.executeSpecificRuneCmd(selectedItem.Rune, true))
 			}
 
+			m.previousState = showingRunes
+			return m, m.runRunes(runesToExecute, key.Matches(msg, m.keys.DryRun), nil)
+
+		case key.Matches(msg, m.keys.ParallelQueue):
+			m.parallelQueue = !m.parallelQueue
+			if m.parallelQueue {
+				m.StatusBar.Content = fmt.Sprintf("Parallel queue: up to %d runes at once", m.concurrency)
+			} else {
+				m.StatusBar.Content = "Sequential queue"
+			}
+			m.StatusBar.Level = statusbar.LevelInfo
+			return m, clearStatusCmd()
+
 		case key.Matches(msg, m.keys.Delete):
 			if len(m.spellbook.Runes) > 0 {
 				selectedItem, ok := m.runesList.SelectedItem().(core.RuneItem)
@@ -555,7 +580,8 @@ func updateShowingRunes(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 					textinputCmdName := fmt.Sprintf("Cmd %d", i+1)
 					t = core.NewTextInput(textinputCmdName, *m.Theme)
 					t.Model.Placeholder = "Command"
-					t.Model.SetValue(cmd)
+					t.Model.SetValue(cmd.Run)
+					t.Policy = cmd.Policy
 					t.Model.SetSuggestions(allSuggestions)
 					m.inputs[2+i] = t
 				}
@@ -641,7 +667,7 @@ func updateShowingRunes(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 		utils.ResetListFilterState(&m.runesList)
 
 		if len(m.spellbook.Runes) > 0 {
-			md := formatRuneDetail(m.spellbook.Runes[0])
+			md := m.formatRuneDetail(m.spellbook.Runes[0])
 			rendered, _ := glamour.Render(md, "dark")
 			m.viewportSpellBook.SetContent(rendered)
 		}
@@ -656,7 +682,7 @@ func updateShowingRunes(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 
 	// When the selected item changes, update the viewport
 	if selectedItem, ok := m.runesList.SelectedItem().(core.RuneItem); ok {
-		md := formatRuneDetail(selectedItem.Rune)
+		md := m.formatRuneDetail(selectedItem.Rune)
 		rendered, _ := glamour.Render(md, "dark")
 		m.viewportSpellBook.SetContent(rendered)
 	}
@@ -664,13 +690,74 @@ func updateShowingRunes(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// setInteractive switches the executing view between navigation keys and
+// forwarding keystrokes to the running command.
+func (m *Model) setInteractive(on bool) {
+	m.interactive = on
+	if on {
+		m.focusedElement = outputViewportElement
+		m.keys = interactiveKeys()
+		m.StatusBar.Content = "Interactive mode: keys are sent to the command"
+		m.StatusBar.Level = statusbar.LevelWarning
+		return
+	}
+	m.keys = executingRuneKeys()
+	m.StatusBar.Content = m.getDefaultStatusBarContent()
+	m.StatusBar.Level = statusbar.LevelInfo
+}
+
 // updateExecutingRune handles updates while a rune is running.
 func updateExecutingRune(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if m.interactive {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			if key.Matches(msg, m.keys.Interactive) {
+				m.setInteractive(false)
+				return m, nil
+			}
+			if e := m.shown(); e != nil && e.terminal != nil {
+				_, _ = e.terminal.Write(local.EncodeKey(msg.Key()))
+			}
+			return m, nil
+		case tea.PasteMsg:
+			if e := m.shown(); e != nil && e.terminal != nil {
+				_, _ = io.WriteString(e.terminal, string(msg))
+			}
+			return m, nil
+		}
+	}
+
+	if m.searching {
+		if msg, ok := msg.(tea.KeyPressMsg); ok {
+			switch msg.String() {
+			case "esc":
+				m.searching = false
+				m.searchInput.Model.Blur()
+				return m, nil
+			case "enter":
+				m.searching = false
+				m.searchInput.Model.Blur()
+				return m, m.search(m.searchInput.Model.Value())
+			}
+			var cmd tea.Cmd
+			m.searchInput, cmd = m.searchInput.Update(msg)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, m.keys.Interactive):
+			if e := m.shown(); e == nil || e.terminal == nil {
+				m.StatusBar.Content = "No command is running"
+				m.StatusBar.Level = statusbar.LevelWarning
+				return m, clearStatusCmd()
+			}
+			m.setInteractive(true)
+			return m, nil
 		case key.Matches(msg, m.keys.SwitchFocus):
 			if m.focusedElement == logsViewportElement {
 				m.focusedElement = outputViewportElement
@@ -678,11 +765,36 @@ func updateExecutingRune(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 				m.focusedElement = logsViewportElement
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.Search):
+			m.searching = true
+			m.searchInput.Model.SetValue("")
+			return m, m.searchInput.Model.Focus()
+		case key.Matches(msg, m.keys.NextMatch), key.Matches(msg, m.keys.PrevMatch):
+			next := key.Matches(msg, m.keys.NextMatch)
+			switch {
+			case m.focusedElement == logsViewportElement && m.logsView != nil && next:
+				m.logsView.NextMatch()
+			case m.focusedElement == logsViewportElement && m.logsView != nil:
+				m.logsView.PreviousMatch()
+			case next:
+				m.executingViewport.HighlightNext()
+			default:
+				m.executingViewport.HighlightPrevious()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.NextOutput):
+			m.showExecution(m.job.shown + 1)
+			return m, nil
+		case key.Matches(msg, m.keys.PrevOutput):
+			m.showExecution(m.job.shown - 1)
+			return m, nil
 		case key.Matches(msg, m.keys.Cancel):
-			if m.currentCancelFunc != nil {
-				m.currentCancelFunc()
+			if e := m.shown(); e != nil && e.status == execution.Running {
+				m.cancelExecution(e)
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.Watch):
+			return m, m.toggleWatch()
 		case key.Matches(msg, m.keys.Yank):
 			if m.focusedElement == logsViewportElement && m.logsView != nil {
 				clipboard.Write(clipboard.FmtText, []byte(m.logsView.GetContent()))
@@ -691,89 +803,36 @@ func updateExecutingRune(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 				return m, clearStatusCmd()
 			}
 			if m.focusedElement == outputViewportElement {
-				clipboard.Write(clipboard.FmtText, []byte(m.output))
+				if e := m.shown(); e != nil && e.plan != "" {
+					clipboard.Write(clipboard.FmtText, []byte(e.plan))
+				} else if e != nil {
+					clipboard.Write(clipboard.FmtText, []byte(e.screen.String()))
+				}
 				m.StatusBar.Content = "Output copied to clipboard!"
 				m.StatusBar.Level = statusbar.LevelSuccess
 				return m, clearStatusCmd()
 			}
 		case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Enter):
-			if m.currentCancelFunc != nil {
-				m.currentCancelFunc()
-			}
-			if m.previousState == showingHistory {
+			var cmd tea.Cmd
+			switch m.previousState {
+			case showingHistory:
 				m.state = showingHistory
 				m.keys = viewingHistoryKeys()
 				m.StatusBar.Content = "Viewing History"
-				m.output = ""
-				m.executingViewport.SetContent("")
-				return m, m.getHistoryCmd
-			}
-			m.state = showingRunes
-			m.keys = viewingRunesKeys()
-			m.StatusBar.Content = "Viewing Runes"
-			return m, nil
-		}
-
-	case runNextCommandMsg:
-		if m.logsView != nil {
-			if m.currentCommandIndex == 0 {
-				m.logsView.AddLog(log.DebugLevel, "Execution started", "rune", m.executingRuneName)
-			}
-			if m.currentCommandIndex < len(m.commandsToExecute) {
-				command := m.commandsToExecute[m.currentCommandIndex]
-				m.logsView.AddLog(log.InfoLevel, "Executing command", "cmd", command)
-			}
-		}
-		return m, m.executeNextCommandCmd()
-
-	case types.RuneCommandOutputMsg:
-		m.output += msg.Output
-		m.executingViewport.SetContent(m.output)
-		m.executingViewport.GotoBottom()
-		return m, waitForOutput(m.msgChan)
-
-	case types.RuneCommandFinished:
-		m.currentCancelFunc = nil // Command is done.
-		if msg.Err != nil {
-			m.logsView.AddLog(log.ErrorLevel, "Command failed, stopping execution", "error", msg.Err)
-			if len(m.executionQueue) > 0 {
-				m.logsView.AddLog(log.ErrorLevel, "Execution queue stopped due to error")
-				m.executionQueue = nil
-				m.executionQueueIndex = 0
-			}
-			m.StatusBar.StopSpinner()
-			m.StatusBar.Content = "Execution failed!"
-			m.StatusBar.Level = statusbar.LevelError
-			return m, clearStatusCmd()
-		}
-
-		m.currentCommandIndex++
-		if m.currentCommandIndex < len(m.commandsToExecute) {
-			return m, func() tea.Msg { return runNextCommandMsg{} }
-		}
-
-		m.logsView.AddLog(log.DebugLevel, "Rune finished", "rune", m.executingRuneName)
-		if len(m.executionQueue) > 0 {
-			m.executionQueueIndex++
-			if m.executionQueueIndex < len(m.executionQueue) {
-				m.logsView.AddSeparator()
-				m.executingViewport.SetContent("")
-				m.output = m.output + "\n\n"
-				return m, m.executeQueuedRuneCmd()
+				cmd = m.getHistoryCmd
+			case showingJobs:
+				m.state = showingJobs
+				m.keys = viewingJobsKeys()
+				m.StatusBar.Content = "Viewing Jobs"
+			default:
+				m.state = showingRunes
+				m.keys = viewingRunesKeys()
+				m.StatusBar.Content = "Viewing Runes"
 			}
-			m.executionQueue = nil
-			m.executionQueueIndex = 0
-			m.StatusBar.StopSpinner()
-			m.StatusBar.Content = "Execution queue finished"
-			m.StatusBar.Level = statusbar.LevelSuccess
-			m.logsView.AddLog(log.DebugLevel, "All runes in queue executed successfully")
-			return m, clearStatusCmd()
+			m.detachJob()
+			return m, cmd
 		}
 
-		m.StatusBar.StopSpinner()
-		m.StatusBar.Content = "Execution finished"
-		m.StatusBar.Level = statusbar.LevelSuccess
-		return m, clearStatusCmd()
 	}
 
 	var cmd tea.Cmd
@@ -979,15 +1038,18 @@ func updateEditingRune(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 		Name:        m.inputs[0].Value(),
 		Description: m.inputs[1].Value(),
 	}
-	var runeCmds []string
-	for i := 2; i < len(m.inputs); i++ {
-		if val := m.inputs[i].Value(); val != "" {
-			runeCmds = append(runeCmds, val)
+	tempRune.Commands = m.formCommands()
+	if m.previousState == showingRunes {
+		if selectedItem, ok := m.runesList.SelectedItem().(core.RuneItem); ok {
+			tempRune.Interpreter = selectedItem.Rune.Interpreter
+			tempRune.Parameters = selectedItem.Rune.Parameters
+			tempRune.Needs = selectedItem.Rune.Needs
+			tempRune.Schedule = selectedItem.Rune.Schedule
+			tempRune.Watch = selectedItem.Rune.Watch
 		}
 	}
-	tempRune.Commands = runeCmds
 
-	md := formatRuneDetail(tempRune)
+	md := m.formatRuneDetail(tempRune)
 	rendered, _ := glamour.Render(md, "dark")
 	m.formViewport.SetContent(rendered)
 
@@ -1118,26 +1180,6 @@ func updateShowingLoegs(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// executeQueuedRuneCmd prepares and executes the current rune in the queue.
-func (m *Model) executeQueuedRuneCmd() tea.Cmd {
-	if m.executionQueueIndex < len(m.executionQueue) {
-		runeToExecute := m.executionQueue[m.executionQueueIndex]
-		if m.logsView != nil {
-			m.logsView.AddLog(
-				log.InfoLevel,
-				"--- PREPARING NEXT RUNE ---",
-				"index",
-				m.executionQueueIndex,
-				"rune",
-				runeToExecute.Name,
-			)
-		}
-		m.executingRuneName = runeToExecute.Name
-		return m.executeSpecificRuneCmd(runeToExecute, false)
-	}
-	return nil
-}
-
 // updateCreatingLoeg handles the form for creating a new loeg.
 func updateCreatingLoeg(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -1249,6 +1291,10 @@ func updateShowingHistory(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 			if m.cursor < len(m.history)-1 {
 				m.cursor++
 			}
+		case key.Matches(msg, m.keys.View):
+			if m.cursor >= 0 && m.cursor < len(m.history) {
+				return m, m.replayHistoryEntry(m.history[m.cursor])
+			}
 		case key.Matches(msg, m.keys.Enter):
 			if m.cursor >= 0 && m.cursor < len(m.history) {
 				selectedEntry := m.history[m.cursor]
@@ -1274,34 +1320,12 @@ func updateShowingHistory(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 
 				if len(runesToExecute) > 0 {
 					m.previousState = showingHistory
-					m.state = executingRune
-					m.keys = executingRuneKeys()
-					m.executingViewport.SetContent("")
-					m.output = ""
-					m.logsView = core.NewLogsView(m.width/3, m.availableHeight, m.Theme)
-					m.focusedElement = logsViewportElement
-					m.recalculateSizes()
-
 					if len(runesToExecute) > 1 {
-						// It's a queue
 						m.StatusBar.Content = "Executing rune queue from history..."
-						m.executionQueue = runesToExecute
-						m.executionQueueIndex = 0
-						var runeIDs []string
-						for _, r := range m.executionQueue {
-							runeIDs = append(runeIDs, r.Name)
-						}
-						if err := m.db.AddHistoryEntry(runeIDs, m.spellbook.Name); err != nil {
-							return m, func() tea.Msg { return errMsg{err} }
-						}
-
-						return m, tea.Batch(m.StatusBar.StartSpinner(), m.executeQueuedRuneCmd())
 					} else {
-						// It's a single rune
 						m.StatusBar.Content = "Executing rune from history..."
-						m.executingRuneName = runesToExecute[0].Name
-						return m, tea.Batch(m.StatusBar.StartSpinner(), m.executeSpecificRuneCmd(runesToExecute[0], true))
 					}
+					return m, m.runRunes(runesToExecute, false, selectedEntry.Params)
 				}
 			}
 		}
@@ -1320,3 +1344,93 @@ func updateShowingHistory(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
 	}
 	return m, nil
 }
+
+// updatePromptingParameters handles the form asking for the parameters of
+// the runes about to run.
+func updatePromptingParameters(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Esc):
+			m.pendingRun = nil
+			m.state = m.previousState
+			if m.state == showingHistory {
+				m.keys = viewingHistoryKeys()
+			} else {
+				m.keys = viewingRunesKeys()
+			}
+			m.StatusBar.Content = m.getDefaultStatusBarContent()
+			m.StatusBar.Level = statusbar.LevelInfo
+			return m, nil
+		case key.Matches(msg, m.keys.Up), key.Matches(msg, m.keys.Down):
+			if key.Matches(msg, m.keys.Up) {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+
+			if m.focusIndex > len(m.inputs) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs)
+			}
+
+			cmds := make([]tea.Cmd, len(m.inputs))
+			for i := range m.inputs {
+				if i == m.focusIndex {
+					cmds[i] = m.inputs[i].Focus()
+					continue
+				}
+				m.inputs[i].Blur()
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.Enter):
+			if m.focusIndex >= len(m.inputs)-1 {
+				return m, m.submitParameters()
+			}
+			m.inputs[m.focusIndex].Blur()
+			m.focusIndex++
+			return m, m.inputs[m.focusIndex].Focus()
+		}
+	}
+
+	cmd := m.updateInputs(msg)
+	return m, cmd
+}
+
+// updateShowingJobs handles the jobs panel.
+func updateShowingJobs(msg tea.Msg, m *Model) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Esc):
+		m.state = ready
+		m.keys = mainListKeys()
+		m.cursor = 0
+		m.StatusBar.Content = m.SpellbookString
+		m.StatusBar.Level = statusbar.LevelInfo
+	case key.Matches(keyMsg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		if m.cursor < len(m.jobs)-1 {
+			m.cursor++
+		}
+	case key.Matches(keyMsg, m.keys.Enter):
+		if j := m.selectedJob(); j != nil {
+			m.previousState = showingJobs
+			m.focusedElement = outputViewportElement
+			m.attachJob(j)
+			m.StatusBar.Content = fmt.Sprintf("Attached to job %d", j.id)
+			m.StatusBar.Level = statusbar.LevelInfo
+			return m, clearStatusCmd()
+		}
+	case key.Matches(keyMsg, m.keys.Cancel):
+		if j := m.selectedJob(); j != nil && !j.done() {
+			return m, m.cancelJob(j)
+		}
+	}
+	return m, nil
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	// "os"
 	"image/color"
+	"sort"
 	"strings"
+	"time"
 
 	"catalyst/internal/ascii"
+	"catalyst/internal/db"
 	"catalyst/internal/types"
 	"catalyst/internal/utils"
 
@@ -230,9 +233,31 @@ func (m *Model) executingRuneFooterLeft(state string) string {
 }
 
 func (m *Model) executingRuneHeaderRight(state string) string {
+	title := "Output"
+	if e := m.shown(); e != nil && len(m.job.executions) > 1 {
+		title = fmt.Sprintf(
+			"Output: %s (%d/%d, %s)",
+			e.rune.Name,
+			m.job.shown+1,
+			len(m.job.executions),
+			e.status,
+		)
+	}
+	if m.job != nil && m.job.dryRun {
+		title += " [dry run]"
+	}
+	if m.job != nil && m.job.replay {
+		title += " [history]"
+	}
+	if m.watch != nil {
+		title += " [watching]"
+	}
+	if m.interactive {
+		title += " [interactive]"
+	}
 	return m.buildStyledBorder(
 		state,
-		"Output",
+		title,
 		HeaderStyle,
 		(m.width * 2 / 3),
 		AlignHeader,
@@ -241,6 +266,14 @@ func (m *Model) executingRuneHeaderRight(state string) string {
 
 func (m *Model) executingRuneFooterRight(state string) string {
 	info := fmt.Sprintf("%3.f%%", m.executingViewport.ScrollPercent()*100)
+	if e := m.shown(); e != nil {
+		if left, ok := e.remaining(); ok {
+			info = fmt.Sprintf("timeout in %s · %s", left.Round(time.Second), info)
+		}
+	}
+	if m.searching {
+		info = m.searchInput.Model.View()
+	}
 	return m.buildStyledBorder(
 		state,
 		info,
@@ -250,16 +283,88 @@ func (m *Model) executingRuneFooterRight(state string) string {
 	)
 }
 
-func formatRuneDetail(rune types.Rune) string {
+// executingBordersHeight returns the height taken by the output header and
+// footer in the executing view.
+func (m *Model) executingBordersHeight() int {
+	return lipgloss.Height(m.executingRuneHeaderRight("blur")) +
+		lipgloss.Height(m.executingRuneFooterRight("blur"))
+}
+
+func (m *Model) formatRuneDetail(rune types.Rune) string {
 	var md strings.Builder
 	md.WriteString(fmt.Sprintf("# %s\n", rune.Name))
 	md.WriteString(fmt.Sprintf("# %s\n", "Description"))
 	md.WriteString(fmt.Sprintf("> %s\n\n", rune.Description))
+	md.WriteString(fmt.Sprintf("# %s\n", "Interpreter"))
+	md.WriteString(fmt.Sprintf("`%s`\n\n", m.runeInterpreter(rune)))
+	if host, _ := m.runeLocation(rune); host != "" {
+		md.WriteString(fmt.Sprintf("# %s\n", "Target"))
+		md.WriteString(fmt.Sprintf("`%s` (%s)\n\n", rune.Target, host))
+	}
+	if len(rune.Needs) > 0 {
+		md.WriteString(fmt.Sprintf("# %s\n", "Needs"))
+		md.WriteString("```\n")
+		md.WriteString(m.needsTree(rune))
+		md.WriteString("```\n")
+		if plan, err := m.spellbook.Plan([]types.Rune{rune}); err != nil {
+			md.WriteString(fmt.Sprintf("> %v\n\n", err))
+		} else {
+			md.WriteString("Runs in this order:\n\n")
+			for i, r := range plan {
+				md.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Name))
+			}
+			md.WriteString("\n")
+		}
+	}
+	if len(rune.Parameters) > 0 {
+		md.WriteString(fmt.Sprintf("# %s\n", "Parameters"))
+		for _, p := range rune.Parameters {
+			md.WriteString(fmt.Sprintf("- `%s`", p.Name))
+			if p.Description != "" {
+				md.WriteString(fmt.Sprintf(": %s", p.Description))
+			}
+			if len(p.Choices) > 0 {
+				md.WriteString(fmt.Sprintf(" (one of %s)", strings.Join(p.Choices, ", ")))
+			}
+			if p.Default != "" {
+				md.WriteString(fmt.Sprintf(", default `%s`", p.Default))
+			}
+			md.WriteString("\n")
+		}
+		md.WriteString("\n")
+	}
+	if rune.Schedule != "" {
+		md.WriteString(fmt.Sprintf("# %s\n", "Schedule"))
+		md.WriteString(m.scheduleDetail(rune))
+	}
+	if len(rune.Watch) > 0 {
+		md.WriteString(fmt.Sprintf("# %s\n", "Watch"))
+		for _, p := range rune.Watch {
+			md.WriteString(fmt.Sprintf("- `%s`\n", p))
+		}
+		md.WriteString("\n")
+	}
 	md.WriteString("```sh\n")
 	for _, cmd := range rune.Commands {
-		md.WriteString(fmt.Sprintf("%s\n", cmd))
+		md.WriteString(fmt.Sprintf("%s\n", cmd.Run))
 	}
 	md.WriteString("```\n")
+
+	// Show the commands as they will run once the loegs are filled in.
+	var rendered strings.Builder
+	templated := false
+	for i, out := range m.spellbook.Preview(rune) {
+		if out != rune.Commands[i].Run {
+			templated = true
+		}
+		rendered.WriteString(fmt.Sprintf("%s\n", out))
+	}
+	if templated {
+		md.WriteString(fmt.Sprintf("# %s\n", "Rendered"))
+		md.WriteString("```sh\n")
+		md.WriteString(rendered.String())
+		md.WriteString("```\n")
+	}
 	return md.String()
 }
 
@@ -470,8 +575,7 @@ func (m *Model) View() string {
 		footerLeft := m.executingRuneFooterLeft(logsState)
 		headerRight := m.executingRuneHeaderRight(outputState)
 		footerRight := m.executingRuneFooterRight(outputState)
-		extraContentHeight := lipgloss.Height(headerRight) + lipgloss.Height(footerRight)
-		m.recalculateSizes(WithExtraContent(extraContentHeight))
+		m.recalculateSizes(WithExtraContent(m.executingBordersHeight()))
 
 		// For now, the left side is empty, but we set it up for future use.
 		var leftSideContent string
@@ -491,8 +595,6 @@ func (m *Model) View() string {
 			)
 		}
 
-		m.executingViewport.SetContent(m.output)
-
 		rightSideContent := lipgloss.JoinVertical(
 			lipgloss.Left,
 			headerRight,
@@ -533,6 +635,28 @@ func (m *Model) View() string {
 		}
 		s.WriteString(fmt.Sprintf("\n%s\n", submitButton))
 
+	case promptingParameters:
+		var names []string
+		for _, r := range m.pendingRun.runes {
+			names = append(names, r.Name)
+		}
+		s.WriteString(fmt.Sprintf("Parameters for %s\n\n", strings.Join(names, ", ")))
+		for i, p := range m.pendingRun.params {
+			s.WriteString(m.inputs[i].View() + "\n")
+			if p.Description != "" {
+				s.WriteString(fmt.Sprintf("    %s\n", p.Description))
+			}
+		}
+
+		submitButton := "Run"
+		if m.pendingRun.dryRun {
+			submitButton = "Dry run"
+		}
+		if m.focusIndex == len(m.inputs) {
+			submitButton = highlight.Render(submitButton)
+		}
+		s.WriteString(fmt.Sprintf("\n%s\n", submitButton))
+
 	case errState:
 		s.WriteString(fmt.Sprintf("An error occurred: %v\n\n", m.err))
 
@@ -546,15 +670,58 @@ func (m *Model) View() string {
 				if m.cursor == i {
 					cursor = ">"
 				}
-				s.WriteString(fmt.Sprintf("%s %s on %s at %s\n",
+				status := ""
+				if entry.Status != "" {
+					status = fmt.Sprintf(" (%s)", entry.Status)
+				}
+				if entry.Trigger != "" && entry.Trigger != db.TriggerManual {
+					status += fmt.Sprintf(" [%s]", entry.Trigger)
+				}
+				names := make([]string, 0, len(entry.Params))
+				for name := range entry.Params {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					status += fmt.Sprintf(" %s=%s", name, entry.Params[name])
+				}
+				s.WriteString(fmt.Sprintf("%s %s on %s at %s%s\n",
 					highlight.Render(cursor),
 					entry.RuneID,
 					entry.SpellbookID,
-					entry.ExecutedAt.Format("2006-01-02 15:04:05")),
+					entry.ExecutedAt.Format("2006-01-02 15:04:05"),
+					status),
 				)
 			}
 		}
 
+	case showingJobs:
+		s.WriteString("Jobs:\n\n")
+		if len(m.jobs) == 0 {
+			s.WriteString("No jobs yet.\n")
+		}
+		for i := range m.jobs {
+			j := m.jobs[len(m.jobs)-1-i]
+			cursor := " "
+			if m.cursor == i {
+				cursor = ">"
+			}
+			over, total := j.progress()
+			name := j.name()
+			if j.trigger != db.TriggerManual {
+				name += fmt.Sprintf(" [%s]", j.trigger)
+			}
+			s.WriteString(fmt.Sprintf("%s #%d %s (%s, %d/%d runes) started at %s, %s\n",
+				highlight.Render(cursor),
+				j.id,
+				name,
+				j.status,
+				over,
+				total,
+				j.started.Format("15:04:05"),
+				j.elapsed().Round(time.Second)),
+			)
+		}
 	}
 
 	uiElements := s.String()
@@ -606,7 +773,7 @@ func renderSpellbook(sb *Spellbook) (string, error) {
 			md.WriteString(fmt.Sprintf("> %s\n\n", r.Description))
 			md.WriteString("```sh\n")
 			for _, cmd := range r.Commands {
-				md.WriteString(fmt.Sprintf("%s\n", cmd))
+				md.WriteString(fmt.Sprintf("%s\n", cmd.Run))
 			}
 			md.WriteString("```\n\n")
 		}
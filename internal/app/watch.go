@@ -0,0 +1,207 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"catalyst/internal/app/components/statusbar"
+	"catalyst/internal/db"
+	"catalyst/internal/types"
+	"catalyst/internal/watch"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/log/v2"
+)
+
+const (
+	// watchInterval is how often watched files are checked for changes.
+	watchInterval = 500 * time.Millisecond
+	// watchDebounce is how long files must stay unchanged before the runes
+	// run again, so saving several files runs them once.
+	watchDebounce = 500 * time.Millisecond
+	// maxChangedFiles is how many changed files are named in the logs.
+	maxChangedFiles = 5
+)
+
+// watchState re-runs the runes of the attached job when the files they watch
+// change.
+type watchState struct {
+	id        int // Tells the ticks of a stopped watch apart
+	runes     []types.Rune
+	params    map[string]string
+	patterns  []string
+	files     watch.Snapshot // Nil until the first scan
+	changed   []string       // Files changed since the last run
+	changedAt time.Time      // When a change was last seen
+	canceling bool           // The running job was canceled to run again
+}
+
+// watchTickMsg checks the files of watch id for changes.
+type watchTickMsg struct{ id int }
+
+func watchTickCmd(id int) tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg { return watchTickMsg{id: id} })
+}
+
+// watchScanMsg is a snapshot of the files of watch id.
+type watchScanMsg struct {
+	id    int
+	files watch.Snapshot
+	err   error
+}
+
+// scanWatchCmd scans the files of watch id in the background. The next
+// tick follows the scan, so slow scans don't pile up.
+func (m *Model) scanWatchCmd(id int) tea.Cmd {
+	w := m.watch
+	if w == nil || w.id != id {
+		return nil
+	}
+	dir, patterns := m.pwd, w.patterns
+	return func() tea.Msg {
+		files, err := watch.Scan(dir, patterns)
+		return watchScanMsg{id: id, files: files, err: err}
+	}
+}
+
+// toggleWatch turns watch mode on or off for the attached job.
+func (m *Model) toggleWatch() tea.Cmd {
+	if m.watch != nil {
+		m.stopWatch()
+		m.StatusBar.Content = "Watch mode off"
+		m.StatusBar.Level = statusbar.LevelInfo
+		return clearStatusCmd()
+	}
+
+	j := m.job
+	if j == nil || j.dryRun || j.replay {
+		m.StatusBar.Content = "Watch mode needs runes that run"
+		m.StatusBar.Level = statusbar.LevelWarning
+		return clearStatusCmd()
+	}
+	runes := make([]types.Rune, len(j.executions))
+	var patterns []string
+	for i, e := range j.executions {
+		runes[i] = e.rune
+		for _, p := range e.rune.Watch {
+			if !slices.Contains(patterns, p) {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+	if len(patterns) == 0 {
+		m.StatusBar.Content = "No watch patterns on these runes"
+		m.StatusBar.Level = statusbar.LevelWarning
+		return clearStatusCmd()
+	}
+	for _, p := range patterns {
+		if err := watch.Validate(p); err != nil {
+			m.StatusBar.Content = err.Error()
+			m.StatusBar.Level = statusbar.LevelError
+			return clearStatusCmd()
+		}
+	}
+
+	// Watch mode is on once the first scan tells which files are watched.
+	m.nextWatchID++
+	m.watch = &watchState{
+		id:       m.nextWatchID,
+		runes:    runes,
+		params:   j.params,
+		patterns: patterns,
+	}
+	return m.scanWatchCmd(m.watch.id)
+}
+
+// stopWatch turns watch mode off.
+func (m *Model) stopWatch() {
+	if m.watch == nil {
+		return
+	}
+	m.watch = nil
+	if m.job != nil {
+		m.job.logsView.AddLog(log.InfoLevel, "Watch mode off")
+	}
+}
+
+// updateWatch compares a scan of the watched files with the previous one
+// and, once they settle after a change, cancels the attached job if it still
+// runs and runs the runes again.
+func (m *Model) updateWatch(msg watchScanMsg) tea.Cmd {
+	w := m.watch
+	if w == nil || w.id != msg.id {
+		return nil
+	}
+	cmds := []tea.Cmd{watchTickCmd(w.id)}
+
+	if w.files == nil {
+		if msg.err != nil {
+			m.stopWatch()
+			m.StatusBar.Content = msg.err.Error()
+			m.StatusBar.Level = statusbar.LevelError
+			return clearStatusCmd()
+		}
+		w.files = msg.files
+		if m.job != nil {
+			m.job.logsView.AddLog(
+				log.InfoLevel,
+				"Watch mode on",
+				"patterns",
+				strings.Join(w.patterns, " "),
+				"files",
+				len(w.files),
+			)
+		}
+		m.StatusBar.Content = "Watch mode on"
+		m.StatusBar.Level = statusbar.LevelInfo
+		return tea.Batch(append(cmds, clearStatusCmd())...)
+	}
+
+	if msg.err == nil {
+		if changed := w.files.Changed(msg.files); len(changed) > 0 {
+			w.files = msg.files
+			w.changedAt = time.Now()
+			for _, name := range changed {
+				if !slices.Contains(w.changed, name) {
+					w.changed = append(w.changed, name)
+				}
+			}
+		}
+	}
+	if len(w.changed) == 0 || time.Since(w.changedAt) < watchDebounce {
+		return tea.Batch(cmds...)
+	}
+
+	if j := m.job; j != nil && !j.done() {
+		if !w.canceling {
+			w.canceling = true
+			j.logsView.AddLog(log.WarnLevel, "Files changed, canceling run", "files", changedFiles(w.changed))
+			cmds = append(cmds, m.cancelJob(j))
+		}
+		// Run again once the canceled commands have stopped.
+		return tea.Batch(cmds...)
+	}
+
+	j, err := m.startJob(w.runes, w.params, db.TriggerWatch)
+	if err != nil {
+		m.stopWatch()
+		m.StatusBar.Content = fmt.Sprintf("Watch mode stopped: %v", err)
+		m.StatusBar.Level = statusbar.LevelError
+		return clearStatusCmd()
+	}
+	j.logsView.AddLog(log.InfoLevel, "Files changed, running again", "files", changedFiles(w.changed))
+	w.changed = nil
+	w.canceling = false
+	m.attachJob(j)
+	return tea.Batch(append(cmds, m.runJob(j))...)
+}
+
+// changedFiles lists changed files for the logs, naming the first few.
+func changedFiles(files []string) string {
+	if len(files) <= maxChangedFiles {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:maxChangedFiles], ", "), len(files)-maxChangedFiles)
+}
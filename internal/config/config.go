@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -10,6 +11,56 @@ import (
 // Config holds the application's configuration.
 type Config struct {
 	RuneCraftHost string `toml:"runecraft_host"`
+	Shell         string `toml:"shell"`
+	Concurrency   int    `toml:"concurrency"`
+	// CommandTimeout limits how long a command may run when its rune doesn't
+	// set a timeout. Zero means no limit.
+	CommandTimeout time.Duration `toml:"command_timeout"`
+	// Dotenv loads the .env file of the spellbook directory into the
+	// environment of rune commands.
+	Dotenv bool `toml:"dotenv"`
+	// Hosts names ssh destinations runes can use as their target.
+	Hosts map[string]string `toml:"hosts"`
+}
+
+// RuneCraftTarget is the rune target that stands for the RuneCraft host.
+const RuneCraftTarget = "runecraft"
+
+// TargetHost resolves a rune target to an ssh destination. It returns an
+// empty string for runes that run locally.
+func (c *Config) TargetHost(target string) string {
+	if target == RuneCraftTarget {
+		return c.RuneCraftHost
+	}
+	if host, ok := c.Hosts[target]; ok {
+		return host
+	}
+	return target
+}
+
+// DefaultConcurrency is how many queued runes run at once in parallel mode
+// when no concurrency is configured.
+const DefaultConcurrency = 4
+
+// ShellCommand returns the shell used to run rune commands. It falls back to
+// $SHELL when no shell is configured, and to /bin/sh when neither is set.
+func (c *Config) ShellCommand() string {
+	if c.Shell != "" {
+		return c.Shell
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// QueueConcurrency returns how many queued runes run at once in parallel
+// mode.
+func (c *Config) QueueConcurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return DefaultConcurrency
 }
 
 // Load loads the configuration from the user's config directory.
@@ -10,6 +10,42 @@ const defaultConfigContent = `# Catalyst Configuration
 #
 # Example:
 # runecraft_host = "runecraft.example.com"
+#
+# shell: The shell used to run rune commands. Runes can override it with
+#        their own interpreter. Defaults to $SHELL when left empty.
+#
+# Example:
+# shell = "bash -euo pipefail"
+#
+# concurrency: How many queued runes run at once when the queue runs in
+#              parallel (ctrl+p in the runes list). Defaults to 4.
+#
+# Example:
+# concurrency = 8
+#
+# command_timeout: How long a command may run before it is stopped, unless
+#                  its rune sets a command_timeout. Unset means no limit.
+#
+# Example:
+# command_timeout = "30m"
+#
+# dotenv: Load the .env file of the spellbook directory into the environment
+#         of rune commands. Loegs are exported too. When a variable is set in
+#         several places, loegs win over .env, which wins over the environment
+#         Catalyst was started with.
+#
+# Example:
+# dotenv = true
+#
+# [hosts]: Named ssh destinations runes can run on by setting their target
+#          to the name. A rune target of "runecraft" runs on runecraft_host.
+#
+# Example:
+# [hosts]
+# staging = "deploy@staging.example.com"
 
 runecraft_host = "localhost"
+shell = ""
+concurrency = 4
+dotenv = false
 `
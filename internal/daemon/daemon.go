@@ -0,0 +1,164 @@
+// Package daemon runs the scheduled runes of a spellbook without the TUI.
+package daemon
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"catalyst/internal/config"
+	"catalyst/internal/db"
+	"catalyst/internal/headless"
+	"catalyst/internal/schedule"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/ssh"
+	"catalyst/internal/types"
+
+	"github.com/charmbracelet/log/v2"
+)
+
+// pollInterval is the longest the daemon sleeps before fetching the
+// spellbook again, so changed schedules are picked up.
+const pollInterval = time.Minute
+
+// Daemon fires the scheduled runes of the spellbook at dir.
+type Daemon struct {
+	cfg    *config.Config
+	db     *db.Database
+	client *ssh.Client
+	dir    string
+	logs   io.Writer
+	logger *log.Logger
+
+	start   time.Time
+	mu      sync.Mutex
+	running map[string]bool      // Scheduled runes running now
+	fired   map[string]time.Time // Last fire of each rune
+	invalid map[string]string    // Invalid schedules already reported
+}
+
+// New creates a daemon for the spellbook at dir. It logs what it does, and
+// the logs of the runs it starts, to logs.
+func New(cfg *config.Config, database *db.Database, dir string, logs io.Writer) *Daemon {
+	return &Daemon{
+		cfg:    cfg,
+		db:     database,
+		client: ssh.NewClient(cfg.RuneCraftHost),
+		dir:    dir,
+		logs:   logs,
+		logger: log.NewWithOptions(logs, log.Options{
+			ReportTimestamp: true,
+			TimeFormat:      "2006-01-02 15:04:05",
+			Prefix:          "daemon",
+		}),
+		running: make(map[string]bool),
+		fired:   make(map[string]time.Time),
+		invalid: make(map[string]string),
+	}
+}
+
+// Run fires scheduled runes until ctx is canceled, then waits for the runs
+// in progress to stop. Runs missed before the daemon started are not caught
+// up.
+func (d *Daemon) Run(ctx context.Context) error {
+	sb, err := spellbook.Fetch(d.client, d.dir)
+	if err != nil {
+		return err
+	}
+	d.start = time.Now()
+	d.logger.Info("Watching schedules", "spellbook", sb.Name, "path", d.dir)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		wake := d.fireDue(ctx, sb, &wg)
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Stopping")
+			return nil
+		case <-time.After(time.Until(wake)):
+		}
+		next, err := spellbook.Fetch(d.client, d.dir)
+		if err != nil {
+			d.logger.Warn("Failed to fetch spellbook, keeping the last one", "error", err)
+			continue
+		}
+		sb = next
+	}
+}
+
+// fireDue starts the scheduled runes of sb that are due and returns when to
+// check again.
+func (d *Daemon) fireDue(ctx context.Context, sb *spellbook.Spellbook, wg *sync.WaitGroup) time.Time {
+	now := time.Now()
+	wake := now.Add(pollInterval)
+	for _, r := range sb.Runes {
+		if r.Schedule == "" {
+			continue
+		}
+		s, err := schedule.Parse(r.Schedule)
+		if err != nil {
+			if d.invalid[r.Name] != r.Schedule {
+				d.invalid[r.Name] = r.Schedule
+				d.logger.Error("Invalid schedule", "rune", r.Name, "error", err)
+			}
+			continue
+		}
+		last, _, err := d.db.LastScheduledRun(sb.Name, r.Name)
+		if err != nil {
+			d.logger.Error("Failed to read history", "rune", r.Name, "error", err)
+			continue
+		}
+
+		d.mu.Lock()
+		if fired := d.fired[r.Name]; fired.After(last) {
+			last = fired
+		}
+		next := schedule.NextRun(s, last, d.start)
+		due := !next.IsZero() && !now.Before(next) && !d.running[r.Name]
+		if due {
+			d.fired[r.Name] = now
+			d.running[r.Name] = true
+			next = s.Next(now)
+		}
+		d.mu.Unlock()
+
+		// A zero next time never comes, and a past one is a rune still
+		// running, which is checked again at the next poll.
+		if next.After(now) && next.Before(wake) {
+			wake = next
+		}
+		if due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.fire(ctx, sb, r)
+			}()
+		}
+	}
+	return wake
+}
+
+// fire runs the scheduled rune r, with the default values of its
+// parameters.
+func (d *Daemon) fire(ctx context.Context, sb *spellbook.Spellbook, r types.Rune) {
+	defer func() {
+		d.mu.Lock()
+		delete(d.running, r.Name)
+		d.mu.Unlock()
+	}()
+
+	executor := headless.NewExecutor(d.cfg, d.db, sb, d.dir)
+	executor.Logs = d.logs
+	if dir, err := db.DataDir(); err == nil {
+		executor.DataDir = dir
+	}
+	d.logger.Info("Firing scheduled rune", "rune", r.Name, "schedule", r.Schedule)
+	result, err := executor.Run(ctx, []types.Rune{r}, nil, db.TriggerScheduled)
+	if err != nil {
+		d.logger.Error("Scheduled rune didn't start", "rune", r.Name, "error", err)
+		return
+	}
+	d.logger.Info("Scheduled rune finished", "rune", r.Name, "status", result.Status, "history", result.HistoryID)
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,20 +19,57 @@ type HistoryEntry struct {
 	RuneID      string
 	SpellbookID string
 	ExecutedAt  time.Time
+	// Status is how the execution ended, one of the Status constants. It is
+	// empty for entries recorded before statuses were kept.
+	Status string
+	// OutputDir is the directory holding the output of the execution, empty
+	// when it wasn't stored.
+	OutputDir string
+	// Params holds the parameter values the runes were run with.
+	Params map[string]string
+	// Trigger is what started the execution, one of the Trigger constants.
+	Trigger string
 }
 
+// Execution statuses recorded in the history.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusTimedOut  = "timed out"
+	StatusCanceled  = "canceled"
+	StatusDryRun    = "dry run"
+)
+
+// Execution triggers recorded in the history.
+const (
+	TriggerManual    = "manual"
+	TriggerScheduled = "scheduled"
+	TriggerWatch     = "watch"
+)
+
 // Database holds the connection pool.
 type Database struct {
 	*sql.DB
 }
 
+// DataDir returns the directory Catalyst keeps its database and execution
+// output in.
+func DataDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "Catalyst"), nil
+}
+
 // InitDB initializes the SQLite database and creates the necessary tables.
 func InitDB() (*Database, error) {
-	configDir, err := os.UserConfigDir()
+	dataDir, err := DataDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user config directory: %w", err)
+		return nil, err
 	}
-	dbPath := filepath.Join(configDir, "Catalyst", "catalyst.db")
+	dbPath := filepath.Join(dataDir, "catalyst.db")
 
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0750); err != nil {
 		return nil, fmt.Errorf("failed to create db directory: %w", err)
@@ -47,30 +86,123 @@ func InitDB() (*Database, error) {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		rune_id TEXT NOT NULL,
 		spellbook_id TEXT NOT NULL,
-		executed_at DATETIME NOT NULL
+		executed_at DATETIME NOT NULL,
+		status TEXT NOT NULL DEFAULT '',
+		output_dir TEXT NOT NULL DEFAULT ''
 	);
 	`
 	if _, err := db.Exec(query); err != nil {
 		return nil, fmt.Errorf("failed to create history table: %w", err)
 	}
+	if err := addColumn(db, "history", "status", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+	if err := addColumn(db, "history", "output_dir", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+	if err := addColumn(db, "history", "params", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+	if err := addColumn(db, "history", "trigger", "TEXT NOT NULL DEFAULT 'manual'"); err != nil {
+		return nil, err
+	}
 
 	return &Database{db}, nil
 }
 
-// AddHistoryEntry inserts a new record into the history table.
-func (db *Database) AddHistoryEntry(runeIDs []string, spellbookID string) error {
+// addColumn adds a column to a table created by an older version, if it
+// doesn't have it yet.
+func addColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to inspect %s table: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s to %s table: %w", column, table, err)
+	}
+	return nil
+}
+
+// AddHistoryEntry inserts a new record into the history table and returns its
+// ID. The entry starts out as running.
+func (db *Database) AddHistoryEntry(runeIDs []string, spellbookID, trigger string, params map[string]string) (int, error) {
 	runeIDStr := strings.Join(runeIDs, ",")
-	query := `INSERT INTO history (rune_id, spellbook_id, executed_at) VALUES (?, ?, ?)`
-	_, err := db.Exec(query, runeIDStr, spellbookID, time.Now())
+	var paramsStr string
+	if len(params) > 0 {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode parameters: %w", err)
+		}
+		paramsStr = string(data)
+	}
+	query := `INSERT INTO history (rune_id, spellbook_id, executed_at, status, params, "trigger") VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := db.Exec(query, runeIDStr, spellbookID, time.Now(), StatusRunning, paramsStr, trigger)
 	if err != nil {
-		return fmt.Errorf("failed to insert history entry: %w", err)
+		return 0, fmt.Errorf("failed to insert history entry: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert history entry: %w", err)
+	}
+	return int(id), nil
+}
+
+// SetHistoryStatus records how the execution of a history entry ended.
+func (db *Database) SetHistoryStatus(id int, status string) error {
+	query := `UPDATE history SET status = ? WHERE id = ?`
+	if _, err := db.Exec(query, status, id); err != nil {
+		return fmt.Errorf("failed to update history entry: %w", err)
 	}
 	return nil
 }
 
+// SetHistoryOutputDir links a history entry to the directory holding the
+// output of its execution.
+func (db *Database) SetHistoryOutputDir(id int, dir string) error {
+	query := `UPDATE history SET output_dir = ? WHERE id = ?`
+	if _, err := db.Exec(query, dir, id); err != nil {
+		return fmt.Errorf("failed to update history entry: %w", err)
+	}
+	return nil
+}
+
+// LastScheduledRun returns when the schedule of a rune of a spellbook last
+// fired. A scheduled run records the runes the rune needs before the rune
+// itself, so the rune is the last one of the entry.
+func (db *Database) LastScheduledRun(spellbookID, runeName string) (time.Time, bool, error) {
+	// The names are compared as they are: LIKE would take _ and % in them
+	// as wildcards, and ignore case.
+	query := `SELECT executed_at FROM history
+		WHERE spellbook_id = ? AND "trigger" = ?
+			AND (rune_id = ? OR substr(rune_id, -length(?) - 1) = ',' || ?)
+		ORDER BY executed_at DESC LIMIT 1`
+	var last time.Time
+	err := db.QueryRow(query, spellbookID, TriggerScheduled, runeName, runeName, runeName).Scan(&last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query history: %w", err)
+	}
+	return last, true, nil
+}
+
 // GetHistory retrieves all execution records from the database.
 func (db *Database) GetHistory() ([]HistoryEntry, error) {
-	query := `SELECT id, rune_id, spellbook_id, executed_at FROM history ORDER BY executed_at DESC`
+	query := `SELECT id, rune_id, spellbook_id, executed_at, status, output_dir, params, "trigger" FROM history ORDER BY executed_at DESC`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query history: %w", err)
@@ -80,9 +212,15 @@ func (db *Database) GetHistory() ([]HistoryEntry, error) {
 	var entries []HistoryEntry
 	for rows.Next() {
 		var entry HistoryEntry
-		if err := rows.Scan(&entry.ID, &entry.RuneID, &entry.SpellbookID, &entry.ExecutedAt); err != nil {
+		var params string
+		if err := rows.Scan(&entry.ID, &entry.RuneID, &entry.SpellbookID, &entry.ExecutedAt, &entry.Status, &entry.OutputDir, &params, &entry.Trigger); err != nil {
 			return nil, fmt.Errorf("failed to scan history row: %w", err)
 		}
+		if params != "" {
+			if err := json.Unmarshal([]byte(params), &entry.Params); err != nil {
+				return nil, fmt.Errorf("invalid parameters in history entry %d: %w", entry.ID, err)
+			}
+		}
 		entries = append(entries, entry)
 	}
 
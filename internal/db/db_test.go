@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastScheduledRun(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	database, err := InitDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	add := func(runes []string, spellbook, trigger string) time.Time {
+		t.Helper()
+		id, err := database.AddHistoryEntry(runes, spellbook, trigger, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var at time.Time
+		if err := database.QueryRow(`SELECT executed_at FROM history WHERE id = ?`, id).Scan(&at); err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+	build := add([]string{"build"}, "app", TriggerScheduled)
+	deploy := add([]string{"build", "deploy"}, "app", TriggerScheduled)
+	add([]string{"setup", "buildax"}, "app", TriggerScheduled)
+	add([]string{"setup", "Deploy"}, "app", TriggerScheduled)
+	add([]string{"deploy"}, "app", TriggerManual)
+	add([]string{"deploy"}, "other", TriggerScheduled)
+
+	tests := []struct {
+		rune   string
+		want   time.Time
+		wantOK bool
+	}{
+		{"build", build, true},
+		{"deploy", deploy, true},
+		{"build%", time.Time{}, false},
+		{"build_x", time.Time{}, false},
+		{"uild", time.Time{}, false},
+		{"test", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok, err := database.LastScheduledRun("app", tt.rune)
+		if err != nil {
+			t.Errorf("LastScheduledRun(%q): %v", tt.rune, err)
+			continue
+		}
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("LastScheduledRun(%q) = %s, %v, want %s, %v", tt.rune, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Package execution decides how the commands of a rune run, for the
+// executing view and for headless runs alike: the status of each rune, when
+// an attempt of a command times out and what a failed attempt leads to.
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"catalyst/internal/db"
+	"catalyst/internal/types"
+)
+
+// Status is how far the execution of a rune got.
+type Status int
+
+const (
+	Pending Status = iota
+	Running
+	Succeeded
+	Failed
+	TimedOut
+	Canceled
+	Skipped
+	DryRun
+	Unknown
+)
+
+// String returns the name of s, as shown in the executing view and stored in
+// run manifests.
+func (s Status) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Running:
+		return "running"
+	case Succeeded:
+		return "done"
+	case Failed:
+		return "failed"
+	case TimedOut:
+		return "timed out"
+	case Canceled:
+		return "canceled"
+	case Skipped:
+		return "skipped"
+	case DryRun:
+		return "dry run"
+	}
+	return "unknown"
+}
+
+// ParseStatus is the inverse of Status.String.
+func ParseStatus(s string) Status {
+	for status := Pending; status <= DryRun; status++ {
+		if status.String() == s {
+			return status
+		}
+	}
+	return Unknown
+}
+
+// RunStatus returns the history status of a run whose runes ended with
+// statuses, and how many of them failed. A timeout outweighs a
+// cancellation, which outweighs a failure.
+func RunStatus(statuses []Status) (status string, failed int) {
+	status = db.StatusSucceeded
+	for _, s := range statuses {
+		switch s {
+		case TimedOut:
+			failed++
+			status = db.StatusTimedOut
+		case Canceled:
+			failed++
+			if status != db.StatusTimedOut {
+				status = db.StatusCanceled
+			}
+		case Failed:
+			failed++
+			if status == db.StatusSucceeded {
+				status = db.StatusFailed
+			}
+		}
+	}
+	return status, failed
+}
+
+// Limits are the timeouts that apply to the commands of a rune.
+type Limits struct {
+	// CommandTimeout applies to commands without a timeout of their own.
+	// Zero means none.
+	CommandTimeout time.Duration
+	// RuneTimeout limits the whole rune, which times out at RuneDeadline.
+	// Zero means none.
+	RuneTimeout  time.Duration
+	RuneDeadline time.Time
+}
+
+// NewLimits returns the limits of the rune r started at start, where
+// commandTimeout is the configured default.
+func NewLimits(r types.Rune, commandTimeout time.Duration, start time.Time) Limits {
+	l := Limits{CommandTimeout: commandTimeout, RuneTimeout: time.Duration(r.Timeout)}
+	if l.RuneTimeout > 0 {
+		l.RuneDeadline = start.Add(l.RuneTimeout)
+	}
+	return l
+}
+
+// Expired reports whether the rune has run out of time at now.
+func (l Limits) Expired(now time.Time) bool {
+	return !l.RuneDeadline.IsZero() && !now.Before(l.RuneDeadline)
+}
+
+// Attempt is when an attempt of a command times out.
+type Attempt struct {
+	// Deadline is zero when the attempt may run for as long as it takes.
+	Deadline time.Time
+	// Timeout is the timeout Deadline comes from.
+	Timeout time.Duration
+}
+
+// Attempt returns when an attempt of a command with policy, started at now,
+// times out: at the command's timeout or at the rune's, whichever comes
+// first.
+func (l Limits) Attempt(now time.Time, policy types.FailurePolicy) Attempt {
+	var a Attempt
+	a.Timeout = time.Duration(policy.CommandTimeout)
+	if a.Timeout <= 0 {
+		a.Timeout = l.CommandTimeout
+	}
+	if a.Timeout > 0 {
+		a.Deadline = now.Add(a.Timeout)
+	}
+	if !l.RuneDeadline.IsZero() && (a.Deadline.IsZero() || l.RuneDeadline.Before(a.Deadline)) {
+		a.Deadline = l.RuneDeadline
+		a.Timeout = l.RuneTimeout
+	}
+	return a
+}
+
+// Expired reports whether the attempt is out of time at now, before it
+// starts when the rune ran out of time waiting to retry.
+func (a Attempt) Expired(now time.Time) bool {
+	return !a.Deadline.IsZero() && !now.Before(a.Deadline)
+}
+
+// Finished completes how the attempt ended with the error of a timeout.
+func (a Attempt) Finished(msg types.RuneCommandFinished) types.RuneCommandFinished {
+	if msg.TimedOut {
+		msg.Err = fmt.Errorf("timed out after %s", a.Timeout)
+	}
+	return msg
+}
+
+// Action is what follows an attempt of a command.
+type Action int
+
+const (
+	// Next goes on with the next command.
+	Next Action = iota
+	// Retry attempts the command again after a delay.
+	Retry
+	// Stop ends the rune.
+	Stop
+)
+
+// Outcome is what an attempt of a command leads to.
+type Outcome struct {
+	Action Action
+	// Delay is how long to wait before retrying.
+	Delay time.Duration
+	// Allowed is set when the attempt failed and the failure policy lets
+	// the rune go on.
+	Allowed bool
+	// Status is the status the rune stops with.
+	Status Status
+}
+
+// Decide applies policy to msg, how an attempt of a command ended after
+// retries earlier attempts. canceled is set when the user canceled the
+// rune, and runeTimedOut when it ran out of time: neither is retried nor
+// allowed to fail.
+func Decide(policy types.FailurePolicy, retries int, msg types.RuneCommandFinished, canceled, runeTimedOut bool) Outcome {
+	switch {
+	case msg.Err == nil:
+		return Outcome{Action: Next}
+	case canceled:
+		return Outcome{Action: Stop, Status: Canceled}
+	case runeTimedOut:
+		return Outcome{Action: Stop, Status: TimedOut}
+	case retries < policy.Retries:
+		return Outcome{Action: Retry, Delay: policy.Delay(retries + 1)}
+	case policy.AllowFailure:
+		return Outcome{Action: Next, Allowed: true}
+	case msg.TimedOut:
+		return Outcome{Action: Stop, Status: TimedOut}
+	}
+	return Outcome{Action: Stop, Status: Failed}
+}
@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"catalyst/internal/db"
+	"catalyst/internal/types"
+)
+
+func TestParseStatus(t *testing.T) {
+	for s := Pending; s <= DryRun; s++ {
+		if got := ParseStatus(s.String()); got != s {
+			t.Errorf("ParseStatus(%q) = %v, want %v", s.String(), got, s)
+		}
+	}
+	if got := ParseStatus("exploded"); got != Unknown {
+		t.Errorf("ParseStatus(%q) = %v, want %v", "exploded", got, Unknown)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		statuses   []Status
+		want       string
+		wantFailed int
+	}{
+		{nil, db.StatusSucceeded, 0},
+		{[]Status{Succeeded, Succeeded}, db.StatusSucceeded, 0},
+		{[]Status{Succeeded, Failed, Skipped}, db.StatusFailed, 1},
+		{[]Status{Failed, Canceled}, db.StatusCanceled, 2},
+		{[]Status{TimedOut, Canceled, Failed}, db.StatusTimedOut, 3},
+		{[]Status{Canceled, TimedOut}, db.StatusTimedOut, 2},
+	}
+	for _, tt := range tests {
+		got, failed := RunStatus(tt.statuses)
+		if got != tt.want || failed != tt.wantFailed {
+			t.Errorf("RunStatus(%v) = %q, %d, want %q, %d", tt.statuses, got, failed, tt.want, tt.wantFailed)
+		}
+	}
+}
+
+func TestAttempt(t *testing.T) {
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		runeTimeout    time.Duration
+		commandTimeout time.Duration // Configured default
+		policy         time.Duration // Timeout of the command
+		at             time.Duration // Since the rune started
+		wantTimeout    time.Duration
+		wantDeadline   time.Duration // Since the rune started, zero for none
+	}{
+		{"no timeouts", 0, 0, 0, 0, 0, 0},
+		{"default", 0, time.Minute, 0, 10 * time.Second, time.Minute, 70 * time.Second},
+		{"command's own", 0, time.Minute, 5 * time.Second, 0, 5 * time.Second, 5 * time.Second},
+		{"rune's first", 30 * time.Second, time.Minute, 0, 10 * time.Second, 30 * time.Second, 30 * time.Second},
+		{"command's first", time.Hour, 0, time.Minute, 0, time.Minute, time.Minute},
+		{"rune's only", time.Minute, 0, 0, 0, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		l := NewLimits(types.Rune{Timeout: types.Duration(tt.runeTimeout)}, tt.commandTimeout, start)
+		a := l.Attempt(start.Add(tt.at), types.FailurePolicy{CommandTimeout: types.Duration(tt.policy)})
+		var want time.Time
+		if tt.wantDeadline > 0 {
+			want = start.Add(tt.wantDeadline)
+		}
+		if a.Timeout != tt.wantTimeout || !a.Deadline.Equal(want) {
+			t.Errorf("%s: Attempt = %s at %s, want %s at %s", tt.name, a.Timeout, a.Deadline, tt.wantTimeout, want)
+		}
+	}
+
+	l := NewLimits(types.Rune{Timeout: types.Duration(time.Minute)}, 0, start)
+	if l.Expired(start.Add(59*time.Second)) || !l.Expired(start.Add(time.Minute)) {
+		t.Error("a rune with a one minute timeout must expire after a minute")
+	}
+	if a := l.Attempt(start.Add(time.Minute), types.FailurePolicy{}); !a.Expired(start.Add(time.Minute)) {
+		t.Error("an attempt started after the rune's deadline must be expired")
+	}
+	msg := l.Attempt(start, types.FailurePolicy{}).Finished(types.RuneCommandFinished{TimedOut: true})
+	if msg.Err == nil || msg.Err.Error() != "timed out after 1m0s" {
+		t.Errorf("Finished error = %v, want timed out after 1m0s", msg.Err)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	failed := types.RuneCommandFinished{Err: errors.New("exit status 1"), ExitCode: 1}
+	timedOut := types.RuneCommandFinished{Err: errors.New("timed out"), ExitCode: -1, TimedOut: true}
+	retry := types.FailurePolicy{Retries: 2, Backoff: types.Duration(time.Second)}
+	allow := types.FailurePolicy{AllowFailure: true}
+	tests := []struct {
+		name         string
+		policy       types.FailurePolicy
+		retries      int
+		msg          types.RuneCommandFinished
+		canceled     bool
+		runeTimedOut bool
+		want         Outcome
+	}{
+		{"succeeded", retry, 0, types.RuneCommandFinished{}, false, false, Outcome{Action: Next}},
+		{"failed", types.FailurePolicy{}, 0, failed, false, false, Outcome{Action: Stop, Status: Failed}},
+		{"timed out", types.FailurePolicy{}, 0, timedOut, false, false, Outcome{Action: Stop, Status: TimedOut}},
+		{"first retry", retry, 0, failed, false, false, Outcome{Action: Retry, Delay: time.Second}},
+		{"second retry", retry, 1, failed, false, false, Outcome{Action: Retry, Delay: 2 * time.Second}},
+		{"retries exhausted", retry, 2, failed, false, false, Outcome{Action: Stop, Status: Failed}},
+		{"timeout retried", retry, 0, timedOut, false, false, Outcome{Action: Retry, Delay: time.Second}},
+		{"allowed", allow, 0, failed, false, false, Outcome{Action: Next, Allowed: true}},
+		{"allowed timeout", allow, 0, timedOut, false, false, Outcome{Action: Next, Allowed: true}},
+		{"canceled", retry, 0, failed, true, false, Outcome{Action: Stop, Status: Canceled}},
+		{"canceled allowed", allow, 0, failed, true, false, Outcome{Action: Stop, Status: Canceled}},
+		{"rune timed out", retry, 0, timedOut, false, true, Outcome{Action: Stop, Status: TimedOut}},
+		{"rune timed out allowed", allow, 0, timedOut, false, true, Outcome{Action: Stop, Status: TimedOut}},
+	}
+	for _, tt := range tests {
+		if got := Decide(tt.policy, tt.retries, tt.msg, tt.canceled, tt.runeTimedOut); got != tt.want {
+			t.Errorf("%s: Decide = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
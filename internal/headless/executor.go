@@ -0,0 +1,360 @@
+// Package headless runs runes without the TUI, for the daemon and the
+// command line. Runs are recorded in the history and their output stored
+// like the ones started from the executing view.
+package headless
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"catalyst/internal/config"
+	"catalyst/internal/db"
+	"catalyst/internal/execution"
+	"catalyst/internal/local"
+	"catalyst/internal/loeg"
+	"catalyst/internal/runlog"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/log/v2"
+)
+
+// Terminal size reported to commands, which have no window to fit.
+const (
+	terminalCols = 120
+	terminalRows = 40
+)
+
+// Executor runs the runes of a spellbook one after another, stopping at the
+// first rune that fails.
+type Executor struct {
+	cfg       *config.Config
+	db        *db.Database
+	runner    *local.Runner
+	spellbook *spellbook.Spellbook
+	dir       string
+
+	// Output receives the output of the commands. Nil discards it.
+	Output io.Writer
+	// Errors, when set, receives the standard error of the commands, which
+	// then run without a terminal. Nil runs them in a terminal, their errors
+	// mixed into Output.
+	Errors io.Writer
+	// Logs receives the log of each run, as shown in the logs view. Nil
+	// discards it.
+	Logs io.Writer
+	// DataDir is where the output of runs is stored. Empty doesn't store it.
+	DataDir string
+}
+
+// NewExecutor creates an executor for the runes of sb, whose path is dir.
+func NewExecutor(cfg *config.Config, database *db.Database, sb *spellbook.Spellbook, dir string) *Executor {
+	return &Executor{
+		cfg:       cfg,
+		db:        database,
+		runner:    local.NewRunner(cfg.ShellCommand()),
+		spellbook: sb,
+		dir:       dir,
+	}
+}
+
+// Result is how a run ended.
+type Result struct {
+	HistoryID int
+	// Status is one of the db status constants.
+	Status string
+	// ExitCode is the exit code of the command that failed the run, 0 when
+	// it succeeded and -1 when the command didn't exit on its own.
+	ExitCode int
+	// Signal is the signal that killed the command that failed the run, if
+	// any.
+	Signal os.Signal
+	// Failed names the rune that failed the run, if any.
+	Failed string
+}
+
+// run is the state of one Run call.
+type run struct {
+	values  map[string]string
+	dotenv  map[string]string
+	logger  *log.Logger
+	output  io.Writer
+	exit    int
+	signal  os.Signal
+	stopped bool // A rune failed, the rest is skipped
+}
+
+// Run runs runes, preceded by the runes they need, and records the run in
+// the history with the given trigger. params overrides the defaults of the
+// runes' parameters. Canceling ctx stops the running command and skips the
+// rest. An error is returned when the run couldn't start.
+func (x *Executor) Run(ctx context.Context, runes []types.Rune, params map[string]string, trigger string) (Result, error) {
+	runes, err := x.spellbook.Plan(runes)
+	if err != nil {
+		return Result{}, err
+	}
+	values, err := types.ParameterValues(types.RuneParameters(x.spellbook.WithSubRunes(runes)), params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	names := make([]string, len(runes))
+	for i, r := range runes {
+		names[i] = r.Name
+	}
+	historyID, err := x.db.AddHistoryEntry(names, x.spellbook.Name, trigger, values)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var logBuf bytes.Buffer
+	logs := x.Logs
+	if logs == nil {
+		logs = io.Discard
+	}
+	r := &run{
+		values: maps.Clone(x.spellbook.Loegs),
+		logger: log.NewWithOptions(io.MultiWriter(&logBuf, logs), log.Options{
+			ReportTimestamp: true,
+			TimeFormat:      "15:04:05.000",
+			Level:           log.InfoLevel,
+		}),
+		output: x.Output,
+	}
+	if r.output == nil {
+		r.output = io.Discard
+	}
+	if r.values == nil {
+		r.values = make(map[string]string, len(values))
+	}
+	maps.Copy(r.values, values)
+	r.logger.Info("Run started", "runes", strings.Join(names, ", "), "trigger", trigger)
+	x.loadDotenv(r)
+
+	store := x.openRun(r, historyID)
+	manifest := make([]runlog.Rune, len(runes))
+	statuses := make([]execution.Status, len(runes))
+	result := Result{HistoryID: historyID}
+	for i, rn := range runes {
+		statuses[i] = execution.Skipped
+		manifest[i] = runlog.Rune{Name: rn.Name, Status: statuses[i].String(), Output: runlog.OutputFile(i)}
+		if r.stopped {
+			r.logger.Warn("Rune skipped", "rune", rn.Name, "reason", "queue stopped")
+			continue
+		}
+		out, errs := r.output, x.Errors
+		var outFile *os.File
+		if store != nil {
+			if outFile, err = store.CreateOutput(i); err != nil {
+				r.logger.Warn("Output won't be stored", "rune", rn.Name, "error", err)
+			} else {
+				out = io.MultiWriter(r.output, outFile)
+				if errs != nil {
+					errs = io.MultiWriter(errs, outFile)
+				}
+			}
+		}
+		status := x.runRune(ctx, r, rn, out, errs)
+		if outFile != nil {
+			_ = outFile.Close()
+		}
+		statuses[i] = status
+		manifest[i].Status = status.String()
+		if status != execution.Succeeded {
+			r.stopped = true
+			result.Failed = rn.Name
+			result.ExitCode = r.exit
+			result.Signal = r.signal
+		}
+	}
+	result.Status, _ = execution.RunStatus(statuses)
+
+	r.logger.Info("Run finished", "status", result.Status)
+	if err := x.db.SetHistoryStatus(historyID, result.Status); err != nil {
+		r.logger.Error("Failed to record execution in history", "error", err)
+	}
+	if store != nil {
+		err := store.WriteManifest(manifest)
+		if err == nil {
+			err = store.WriteLog(logBuf.String())
+		}
+		if err != nil {
+			r.logger.Warn("Failed to store execution output", "error", err)
+		}
+	}
+	return result, nil
+}
+
+// loadDotenv loads the .env file of the spellbook directory for r, if
+// enabled.
+func (x *Executor) loadDotenv(r *run) {
+	if !x.cfg.Dotenv {
+		return
+	}
+	dotenv, err := loeg.LoadDotenv(x.dir)
+	if err != nil {
+		r.logger.Warn("Ignoring .env file", "error", err)
+		return
+	}
+	r.dotenv = dotenv
+}
+
+// openRun prepares storing the output of the run recorded as history entry
+// id. Runs still happen when their output can't be stored.
+func (x *Executor) openRun(r *run, id int) *runlog.Run {
+	if x.DataDir == "" {
+		return nil
+	}
+	store, err := runlog.Create(x.DataDir, id)
+	if err == nil {
+		err = x.db.SetHistoryOutputDir(id, store.Dir)
+	}
+	if err != nil {
+		r.logger.Warn("Output won't be stored", "error", err)
+		return nil
+	}
+	return store
+}
+
+// runRune runs the commands of rn, sub-runes expanded, and returns how it
+// ended. Their output goes to out, and their errors to errs when it isn't
+// nil.
+func (x *Executor) runRune(ctx context.Context, r *run, rn types.Rune, out, errs io.Writer) execution.Status {
+	steps, err := x.spellbook.Expand(rn)
+	if err != nil {
+		r.logger.Error("Rune failed", "rune", rn.Name, "error", err)
+		r.exit = -1
+		return execution.Failed
+	}
+	limits := execution.NewLimits(rn, x.cfg.CommandTimeout, time.Now())
+
+	r.logger.Info("Execution started", "rune", rn.Name)
+	for i, step := range steps {
+		if len(step.Path) > 1 && (i == 0 || !slices.Equal(steps[i-1].Path, step.Path)) {
+			r.logger.Info("Running sub-rune", "rune", step.Origin(), "depth", len(step.Path)-1)
+		}
+		if status := x.runStep(ctx, r, limits, step, out, errs); status != execution.Succeeded {
+			r.logger.Error("Rune failed", "rune", rn.Name, "reason", status)
+			return status
+		}
+	}
+	r.logger.Info("Rune finished", "rune", rn.Name)
+	return execution.Succeeded
+}
+
+// runStep runs the command of step, retrying it as its failure policy says,
+// and returns Succeeded when the rune goes on with the next command.
+func (x *Executor) runStep(ctx context.Context, r *run, limits execution.Limits, step spellbook.Step, out, errs io.Writer) execution.Status {
+	origin := step.Origin()
+	command, err := loeg.Render(step.Command, r.values)
+	if err != nil {
+		r.logger.Error("Command not run", "rune", origin, "error", err)
+		r.exit = -1
+		return execution.Failed
+	}
+
+	for retries := 0; ; {
+		if retries > 0 {
+			r.logger.Info("Retrying command", "rune", origin, "attempt", retries+1, "of", step.Policy.Retries+1)
+		} else {
+			r.logger.Info("Executing command", "rune", origin, "cmd", step.Command)
+		}
+		attempt := limits.Attempt(time.Now(), step.Policy)
+		msg := types.RuneCommandFinished{ExitCode: -1, TimedOut: true}
+		if !attempt.Expired(time.Now()) {
+			msg = x.runCommand(ctx, r, step, command, attempt.Deadline, out, errs)
+		}
+		msg = attempt.Finished(msg)
+
+		outcome := execution.Decide(step.Policy, retries, msg, ctx.Err() != nil, limits.Expired(time.Now()))
+		switch {
+		case outcome.Action == execution.Retry:
+			r.logger.Warn("Command failed, retrying", "rune", origin, "error", msg.Err, "exit", msg.ExitCode, "delay", outcome.Delay)
+			select {
+			case <-time.After(outcome.Delay):
+				retries++
+				continue
+			case <-ctx.Done():
+				r.exit = -1
+				return execution.Canceled
+			}
+		case outcome.Allowed:
+			r.logger.Warn("Command failed, continuing (allow_failure)", "rune", origin, "error", msg.Err, "exit", msg.ExitCode)
+			return execution.Succeeded
+		case outcome.Action == execution.Next:
+			r.logger.Info("Command finished", "rune", origin, "exit", msg.ExitCode, "wall", msg.Duration.Round(time.Millisecond))
+			return execution.Succeeded
+		}
+		r.exit = msg.ExitCode
+		r.signal = msg.Signal
+		r.logger.Error("Command failed, stopping rune", "rune", origin, "error", msg.Err, "exit", msg.ExitCode)
+		return outcome.Status
+	}
+}
+
+// runCommand runs command once, until deadline if it isn't zero. Without
+// errs, it runs in a terminal whose output is copied to out.
+func (x *Executor) runCommand(ctx context.Context, r *run, step spellbook.Step, command string, deadline time.Time, out, errs io.Writer) types.RuneCommandFinished {
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	msgChan := make(chan tea.Msg)
+	go func() {
+		defer close(msgChan)
+		x.runner.ExecuteCommand(ctx, command, msgChan, x.execOptions(r, step.Rune, out, errs)...)
+	}()
+
+	var terminal types.Terminal
+	var result types.RuneCommandFinished
+	for msg := range msgChan {
+		switch msg := msg.(type) {
+		case types.RuneCommandStarted:
+			terminal = msg.Terminal
+		case types.RuneCommandSignaled:
+			r.logger.Warn("Stopping command", "rune", step.Origin(), "signal", msg.Signal)
+		case types.RuneCommandCursorQuery:
+			// There is no screen to track the cursor on.
+			if terminal != nil {
+				prefix := ""
+				if msg.Extended {
+					prefix = "?"
+				}
+				_, _ = fmt.Fprintf(terminal, "\x1b[%s1;1R", prefix)
+			}
+		case types.RuneCommandOutputMsg:
+			_, _ = io.WriteString(out, msg.Output)
+		case types.RuneCommandFinished:
+			result = msg
+		}
+	}
+	return result
+}
+
+// execOptions returns how the commands of rn are run, writing to out and
+// errs as runCommand does.
+func (x *Executor) execOptions(r *run, rn types.Rune, out, errs io.Writer) []local.ExecOption {
+	options := []local.ExecOption{
+		local.WithInterpreter(rn.Interpreter),
+		local.WithEnv(loeg.Environ(loeg.Merge(nil, r.dotenv, x.spellbook.Loegs))),
+	}
+	if errs != nil {
+		options = append(options, local.WithPipes(out, errs))
+	} else {
+		options = append(options, local.WithSize(terminalCols, terminalRows))
+	}
+	if host := x.cfg.TargetHost(rn.Target); host != "" {
+		return append(options, local.WithTarget(host), local.WithDir(rn.Workdir))
+	}
+	return append(options, local.WithDir(local.WorkDir(x.dir, rn.Workdir)))
+}
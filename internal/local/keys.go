@@ -0,0 +1,113 @@
+package local
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// functionKeys maps F1-F12 to the sequences xterm sends for them.
+var functionKeys = map[rune]string{
+	tea.KeyF1:  "\x1bOP",
+	tea.KeyF2:  "\x1bOQ",
+	tea.KeyF3:  "\x1bOR",
+	tea.KeyF4:  "\x1bOS",
+	tea.KeyF5:  "\x1b[15~",
+	tea.KeyF6:  "\x1b[17~",
+	tea.KeyF7:  "\x1b[18~",
+	tea.KeyF8:  "\x1b[19~",
+	tea.KeyF9:  "\x1b[20~",
+	tea.KeyF10: "\x1b[21~",
+	tea.KeyF11: "\x1b[23~",
+	tea.KeyF12: "\x1b[24~",
+}
+
+// cursorKeys maps cursor keys to the final byte of their CSI sequence.
+var cursorKeys = map[rune]byte{
+	tea.KeyUp:    'A',
+	tea.KeyDown:  'B',
+	tea.KeyRight: 'C',
+	tea.KeyLeft:  'D',
+	tea.KeyHome:  'H',
+	tea.KeyEnd:   'F',
+}
+
+// editingKeys maps editing keys to the number of their "CSI n ~" sequence.
+var editingKeys = map[rune]int{
+	tea.KeyInsert: 2,
+	tea.KeyDelete: 3,
+	tea.KeyPgUp:   5,
+	tea.KeyPgDown: 6,
+}
+
+// EncodeKey returns the bytes a terminal would send to a program for the key
+// press k, or nil when the key has no terminal representation.
+func EncodeKey(k tea.Key) []byte {
+	ctrl := k.Mod&tea.ModCtrl != 0
+	alt := k.Mod&tea.ModAlt != 0
+	shift := k.Mod&tea.ModShift != 0
+
+	// xterm encodes modifiers on special keys as 1 + shift|alt<<1|ctrl<<2.
+	modParam := 1
+	if shift {
+		modParam += 1
+	}
+	if alt {
+		modParam += 2
+	}
+	if ctrl {
+		modParam += 4
+	}
+
+	if final, ok := cursorKeys[k.Code]; ok {
+		if modParam > 1 {
+			return fmt.Appendf(nil, "\x1b[1;%d%c", modParam, final)
+		}
+		return []byte{0x1b, '[', final}
+	}
+	if n, ok := editingKeys[k.Code]; ok {
+		if modParam > 1 {
+			return fmt.Appendf(nil, "\x1b[%d;%d~", n, modParam)
+		}
+		return fmt.Appendf(nil, "\x1b[%d~", n)
+	}
+	if seq, ok := functionKeys[k.Code]; ok {
+		return []byte(seq)
+	}
+
+	var seq []byte
+	switch k.Code {
+	case tea.KeyEnter, tea.KeyKpEnter:
+		seq = []byte{'\r'}
+	case tea.KeyTab:
+		if shift {
+			return []byte("\x1b[Z")
+		}
+		seq = []byte{'\t'}
+	case tea.KeyBackspace:
+		seq = []byte{0x7f}
+	case tea.KeyEscape:
+		seq = []byte{0x1b}
+	default:
+		switch {
+		case ctrl && k.Code >= 'a' && k.Code <= 'z':
+			seq = []byte{byte(k.Code-'a') + 1}
+		case ctrl && (k.Code == tea.KeySpace || k.Code == '@'):
+			seq = []byte{0}
+		case ctrl && k.Code >= '[' && k.Code <= '_':
+			seq = []byte{byte(k.Code) & 0x1f}
+		case k.Text != "":
+			seq = []byte(k.Text)
+		case k.Code == tea.KeySpace:
+			seq = []byte{' '}
+		case k.Code > 0 && k.Code <= utf8.MaxRune:
+			seq = utf8.AppendRune(nil, k.Code)
+		}
+	}
+
+	if alt && len(seq) > 0 {
+		seq = append([]byte{0x1b}, seq...)
+	}
+	return seq
+}
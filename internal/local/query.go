@@ -0,0 +1,192 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+)
+
+// maxPending bounds how much of an unfinished escape sequence is held back
+// while waiting for the rest of it. Longer sequences can't be queries.
+const maxPending = 4096
+
+const (
+	stateGround = iota
+	stateEsc
+	stateCsi
+	stateOsc
+	stateOscEsc
+	stateString
+	stateStringEsc
+)
+
+// queryFilter scans command output for terminal queries (device status,
+// device attributes, version and color requests) and answers them the way a
+// terminal would. Queries are removed from the output; everything else is
+// passed through unchanged. Sequences split across reads are reassembled.
+type queryFilter struct {
+	// output receives the bytes to display, in order.
+	output func([]byte)
+	// reply writes an answer back to the program.
+	reply func([]byte)
+	// cursor is called for cursor position queries, which only the UI can
+	// answer because it owns the screen. Output preceding the query has
+	// already been passed to output.
+	cursor func(extended bool)
+
+	foreground color.Color
+	background color.Color
+	version    string
+
+	state   int
+	pending []byte
+	buf     []byte
+}
+
+// Write processes a chunk of command output.
+func (f *queryFilter) Write(p []byte) {
+	for _, b := range p {
+		f.advance(b)
+	}
+	if f.state != stateGround && len(f.pending) > maxPending {
+		f.buf = append(f.buf, f.pending...)
+		f.pending = f.pending[:0]
+		f.state = stateGround
+	}
+	f.flush()
+}
+
+// Close passes any unfinished sequence through as output.
+func (f *queryFilter) Close() {
+	f.buf = append(f.buf, f.pending...)
+	f.pending = nil
+	f.state = stateGround
+	f.flush()
+}
+
+func (f *queryFilter) flush() {
+	if len(f.buf) > 0 {
+		f.output(f.buf)
+		f.buf = nil
+	}
+}
+
+func (f *queryFilter) advance(b byte) {
+	switch f.state {
+	case stateGround:
+		if b == 0x1b {
+			f.pending = append(f.pending[:0], b)
+			f.state = stateEsc
+			return
+		}
+		f.buf = append(f.buf, b)
+	case stateEsc:
+		f.pending = append(f.pending, b)
+		switch b {
+		case '[':
+			f.state = stateCsi
+		case ']':
+			f.state = stateOsc
+		case 'P', '_', '^', 'X':
+			f.state = stateString
+		default:
+			if b >= 0x20 && b <= 0x2f {
+				// Intermediate bytes, e.g. character set designations.
+				return
+			}
+			f.done(false)
+		}
+	case stateCsi:
+		f.pending = append(f.pending, b)
+		if b >= 0x40 && b <= 0x7e {
+			f.done(f.csiQuery())
+		}
+	case stateOsc:
+		switch b {
+		case 0x07:
+			f.pending = append(f.pending, b)
+			f.done(f.oscQuery(string(f.pending[2:len(f.pending)-1]), "\x07"))
+		case 0x1b:
+			f.pending = append(f.pending, b)
+			f.state = stateOscEsc
+		default:
+			f.pending = append(f.pending, b)
+		}
+	case stateOscEsc:
+		f.pending = append(f.pending, b)
+		if b == '\\' {
+			f.done(f.oscQuery(string(f.pending[2:len(f.pending)-2]), "\x1b\\"))
+			return
+		}
+		f.state = stateOsc
+	case stateString:
+		f.pending = append(f.pending, b)
+		if b == 0x1b {
+			f.state = stateStringEsc
+		}
+	case stateStringEsc:
+		f.pending = append(f.pending, b)
+		if b == '\\' {
+			f.done(false)
+			return
+		}
+		f.state = stateString
+	}
+}
+
+// done finishes the pending sequence, dropping it if it was a query.
+func (f *queryFilter) done(handled bool) {
+	if !handled {
+		f.buf = append(f.buf, f.pending...)
+	}
+	f.pending = f.pending[:0]
+	f.state = stateGround
+}
+
+// csiQuery answers the pending CSI sequence if it is a query.
+func (f *queryFilter) csiQuery() bool {
+	body := f.pending[2:]
+	switch string(body) {
+	case "6n", "?6n":
+		f.flush()
+		f.cursor(body[0] == '?')
+	case "5n":
+		f.reply([]byte("\x1b[0n"))
+	case "c", "0c":
+		// VT220 with ANSI color.
+		f.reply([]byte("\x1b[?62;22c"))
+	case ">c", ">0c":
+		f.reply([]byte("\x1b[>1;10;0c"))
+	case ">q", ">0q":
+		f.reply(fmt.Appendf(nil, "\x1bP>|Catalyst %s\x1b\\", f.version))
+	default:
+		return false
+	}
+	return true
+}
+
+// oscQuery answers foreground and background color queries. The answer uses
+// the same terminator as the query.
+func (f *queryFilter) oscQuery(body, terminator string) bool {
+	var c color.Color
+	switch body {
+	case "10;?":
+		c = f.foreground
+	case "11;?":
+		c = f.background
+	default:
+		return false
+	}
+	if c == nil {
+		return false
+	}
+	cmd, _, _ := bytes.Cut([]byte(body), []byte(";"))
+	f.reply(fmt.Appendf(nil, "\x1b]%s;%s%s", cmd, xrgb(c), terminator))
+	return true
+}
+
+// xrgb formats c the way xterm reports colors, e.g. "rgb:2020/1f1f/2626".
+func xrgb(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", r, g, b)
+}
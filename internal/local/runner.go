@@ -1,80 +1,249 @@
 package local
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"image/color"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"catalyst/internal/ssh"
 	"catalyst/internal/types"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/creack/pty"
 )
 
-var (
-	// Terminal query sequences that cause timeouts.
-	cursorPosQuery = []byte("\x1b[6n")
-	bgColorQuery   = []byte("\x1b]11;?\x1b\\")
-)
-
 // Runner executes local shell commands.
-type Runner struct{}
+type Runner struct {
+	// Shell is the default interpreter for commands, e.g. "zsh" or "bash -eu".
+	Shell string
+
+	// Foreground, Background and Version are reported to programs that query
+	// their terminal's colors or version.
+	Foreground color.Color
+	Background color.Color
+	Version    string
+
+	// GracePeriod is how long a canceled command gets to exit after SIGINT,
+	// and again after SIGTERM, before it is killed.
+	GracePeriod time.Duration
+}
+
+// DefaultGracePeriod is the grace period of a new Runner.
+const DefaultGracePeriod = 5 * time.Second
 
-// NewRunner creates a new command runner.
-func NewRunner() *Runner {
-	return &Runner{}
+// NewRunner creates a new command runner that uses shell by default.
+func NewRunner(shell string) *Runner {
+	return &Runner{Shell: shell, GracePeriod: DefaultGracePeriod}
+}
+
+type execOptions struct {
+	interpreter string
+	dir         string
+	target      string
+	env         []string
+	cols, rows  int
+
+	stdout, stderr io.Writer
+}
+
+// ExecOption customizes how a single command is executed.
+type ExecOption func(*execOptions)
+
+// WithInterpreter runs the command with interpreter instead of the runner's
+// default shell. An empty interpreter keeps the default.
+func WithInterpreter(interpreter string) ExecOption {
+	return func(opts *execOptions) {
+		opts.interpreter = interpreter
+	}
+}
+
+// WithDir runs the command in dir instead of the Catalyst process directory.
+func WithDir(dir string) ExecOption {
+	return func(opts *execOptions) {
+		opts.dir = dir
+	}
+}
+
+// WithTarget runs the command over ssh on host instead of locally. The
+// directory set with WithDir is then a directory on host, and without an
+// interpreter the command is run by the remote login shell.
+func WithTarget(host string) ExecOption {
+	return func(opts *execOptions) {
+		opts.target = host
+	}
+}
+
+// WithEnv adds "NAME=value" variables to the command's environment. They
+// take precedence over the Catalyst process environment, which isn't passed
+// to commands running on a target.
+func WithEnv(env []string) ExecOption {
+	return func(opts *execOptions) {
+		opts.env = env
+	}
+}
+
+// WithSize sets the initial window size of the command's terminal.
+func WithSize(cols, rows int) ExecOption {
+	return func(opts *execOptions) {
+		opts.cols, opts.rows = cols, rows
+	}
+}
+
+// WithPipes runs the command without a terminal: its standard output and
+// error are written to stdout and stderr instead of being sent as
+// RuneCommandOutputMsg, and its standard input is empty. Commands running on
+// a target get no remote terminal either. RuneCommandStarted then has no
+// Terminal and no RuneCommandCursorQuery is sent.
+func WithPipes(stdout, stderr io.Writer) ExecOption {
+	return func(opts *execOptions) {
+		opts.stdout, opts.stderr = stdout, stderr
+	}
+}
+
+// WorkDir resolves a rune's working directory against the spellbook path.
+// Relative directories are joined to spellbookPath; an empty workdir resolves
+// to spellbookPath itself.
+func WorkDir(spellbookPath, workdir string) string {
+	if workdir == "" {
+		return spellbookPath
+	}
+	if filepath.IsAbs(workdir) {
+		return filepath.Clean(workdir)
+	}
+	return filepath.Join(spellbookPath, workdir)
+}
+
+// Interpreter returns the interpreter used for a rune that declares
+// interpreter, falling back to the runner's shell.
+func (r *Runner) Interpreter(interpreter string) string {
+	if strings.TrimSpace(interpreter) != "" {
+		return interpreter
+	}
+	return r.Shell
+}
+
+// inlineCodeE lists the interpreters that take inline code after "-e". For
+// shells "-e" is errexit.
+var inlineCodeE = []string{"node", "nodejs", "perl", "ruby"}
+
+// CommandLine returns the argv used to run command with interpreter.
+// The command is passed as the last argument; "-c" is appended unless the
+// interpreter already ends with its code flag: "-c" (as in "python3 -c"), or
+// "-e" for node, perl and ruby (as in "node -e").
+func CommandLine(interpreter, command string) []string {
+	args := strings.Fields(interpreter)
+	if len(args) == 0 {
+		args = []string{"/bin/sh"}
+	}
+	if !endsWithCodeFlag(args) {
+		args = append(args, "-c")
+	}
+	return append(args, command)
+}
+
+// endsWithCodeFlag reports whether the interpreter args end with the flag
+// that makes the interpreter run the next argument as code.
+func endsWithCodeFlag(args []string) bool {
+	switch args[len(args)-1] {
+	case "-c":
+		return true
+	case "-e":
+		program := args[0]
+		if filepath.Base(program) == "env" && len(args) > 2 {
+			program = args[1]
+		}
+		// Versioned binaries, like perl5.36, take the flag too.
+		name := strings.TrimRight(filepath.Base(program), "0123456789.")
+		return slices.Contains(inlineCodeE, name)
+	}
+	return false
 }
 
 // ExecuteCommand runs a single command and streams its output.
 // It sends RuneCommandOutputMsg for output and RuneCommandFinished when done.
-func (r *Runner) ExecuteCommand(ctx context.Context, command string, msgChan chan<- tea.Msg) {
-	cmd := exec.CommandContext(ctx, "zsh", "-c", command)
-	cmd.Env = os.Environ()
+//
+// The command runs in its own process group. Canceling ctx, or reaching its
+// deadline, stops the whole group: SIGINT first, then SIGTERM and SIGKILL if
+// it is still running after the grace period. RuneCommandFinished is only
+// sent once the group is gone.
+func (r *Runner) ExecuteCommand(ctx context.Context, command string, msgChan chan<- tea.Msg, options ...ExecOption) {
+	opts := &execOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	cmd := r.command(command, opts)
+	if opts.stdout != nil {
+		r.executePiped(ctx, cmd, msgChan)
+		return
+	}
 
-	ptmx, err := pty.Start(cmd)
+	term := &ptyTerminal{cols: opts.cols, rows: opts.rows}
+	start := time.Now()
+	ptmx, err := pty.StartWithSize(cmd, term.winsize())
 	if err != nil {
-		msgChan <- types.RuneCommandFinished{Err: err}
+		msgChan <- types.RuneCommandFinished{Err: err, ExitCode: -1}
 		return
 	}
 	defer func() { _ = ptmx.Close() }()
+	term.ptmx = ptmx
+
+	// The pty starts the command in a new session, which makes it the leader
+	// of its own process group.
+	group := processGroup(cmd.Process.Pid)
+	msgChan <- types.RuneCommandStarted{Terminal: term, Process: group}
+
+	filter := &queryFilter{
+		output: func(data []byte) {
+			msgChan <- types.RuneCommandOutputMsg{Output: string(data)}
+		},
+		reply: func(answer []byte) {
+			_, _ = ptmx.Write(answer)
+		},
+		cursor: func(extended bool) {
+			msgChan <- types.RuneCommandCursorQuery{Extended: extended}
+		},
+		foreground: r.Foreground,
+		background: r.Background,
+		version:    r.Version,
+	}
+
+	interrupt := func() error { return group.signal(syscall.SIGINT) }
+	if opts.target != "" {
+		// Interrupt the remote command through its terminal; ssh itself
+		// would just drop the connection.
+		interrupt = func() error {
+			_, err := ptmx.Write([]byte{0x03})
+			return err
+		}
+	}
+	exited := make(chan struct{})
+	stopped := r.stopWhenDone(ctx, group, interrupt, exited, msgChan)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	// Goroutine to stream stdout and handle terminal queries.
+	// Goroutine to stream output and answer terminal queries.
 	go func() {
 		defer wg.Done()
+		defer filter.Close()
 		buffer := make([]byte, 4096)
 		for {
 			n, err := ptmx.Read(buffer)
 			if n <= 0 {
-				if err != nil {
-					// Handle read error, though it usually just means the pty closed.
-				}
+				// A read error usually just means the pty closed.
 				return
 			}
 
-			data := buffer[:n]
-
-			// --- This is the core fix ---
-			// Check for and respond to terminal queries to prevent timeouts.
-			if bytes.Contains(data, cursorPosQuery) {
-				_, _ = ptmx.Write([]byte("\x1b[1;1R")) // Respond: "cursor is at 1;1"
-				data = bytes.ReplaceAll(data, cursorPosQuery, []byte(""))
-			}
-			if bytes.Contains(data, bgColorQuery) {
-				// Respond: "background is black"
-				_, _ = ptmx.Write([]byte("\x1b]11;rgb:0000/0000/0000\x07"))
-				data = bytes.ReplaceAll(data, bgColorQuery, []byte(""))
-			}
-			// --- End of fix ---
-
-			// Send the cleaned output (without queries) to the UI.
-			if len(data) > 0 {
-				msgChan <- types.RuneCommandOutputMsg{Output: string(data)}
-			}
+			filter.Write(buffer[:n])
 
 			if err != nil {
 				return
@@ -84,13 +253,189 @@ func (r *Runner) ExecuteCommand(ctx context.Context, command string, msgChan cha
 
 	// Wait for the command to finish.
 	processErr := cmd.Wait()
+	close(exited)
 
 	// Now that the process is done, close the PTY.
 	_ = ptmx.Close()
 
-	// Wait for the reader to finish flushing any remaining output.
+	// Wait for the reader to finish flushing any remaining output, and for a
+	// canceled command's process group to be gone.
 	wg.Wait()
 
 	// Send the final message.
-	msgChan <- types.RuneCommandFinished{Err: processErr}
+	msg := finished(processErr, cmd.ProcessState, time.Since(start))
+	msg.TimedOut = <-stopped && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	msgChan <- msg
+}
+
+// executePiped runs cmd like ExecuteCommand, without a terminal, as set up
+// by WithPipes.
+func (r *Runner) executePiped(ctx context.Context, cmd *exec.Cmd, msgChan chan<- tea.Msg) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// Background processes the command leaves behind may keep its output
+	// open; they don't hold up the command for longer than this.
+	cmd.WaitDelay = r.GracePeriod
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		msgChan <- types.RuneCommandFinished{Err: err, ExitCode: -1}
+		return
+	}
+	group := processGroup(cmd.Process.Pid)
+	msgChan <- types.RuneCommandStarted{Process: group}
+
+	exited := make(chan struct{})
+	interrupt := func() error { return group.signal(syscall.SIGINT) }
+	stopped := r.stopWhenDone(ctx, group, interrupt, exited, msgChan)
+
+	processErr := cmd.Wait()
+	if errors.Is(processErr, exec.ErrWaitDelay) {
+		processErr = nil
+	}
+	close(exited)
+
+	msg := finished(processErr, cmd.ProcessState, time.Since(start))
+	msg.TimedOut = <-stopped && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	msgChan <- msg
+}
+
+// stopWhenDone stops group, starting with interrupt, when ctx is done before
+// exited is closed. The returned channel reports whether it did, once the group is
+// gone or the command exited.
+func (r *Runner) stopWhenDone(ctx context.Context, group processGroup, interrupt func() error, exited <-chan struct{}, msgChan chan<- tea.Msg) <-chan bool {
+	stopped := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.stop(group, interrupt, msgChan)
+			stopped <- true
+		case <-exited:
+			stopped <- false
+		}
+	}()
+	return stopped
+}
+
+// stop interrupts group, then sends it SIGTERM and SIGKILL in turn, waiting
+// up to the grace period for it to exit after each signal.
+func (r *Runner) stop(group processGroup, interrupt func() error, msgChan chan<- tea.Msg) {
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL} {
+		if !group.alive() {
+			return
+		}
+		send := func() error { return group.signal(sig) }
+		if sig == syscall.SIGINT {
+			send = interrupt
+		}
+		if err := send(); err != nil {
+			return
+		}
+		msgChan <- types.RuneCommandSignaled{Signal: sig}
+		deadline := time.Now().Add(r.GracePeriod)
+		for group.alive() && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// processGroup is the process group of a running command, identified by the
+// pid of its leader.
+type processGroup int
+
+func (g processGroup) signal(sig syscall.Signal) error {
+	return syscall.Kill(-int(g), sig)
+}
+
+// alive reports whether any process of the group is still running.
+func (g processGroup) alive() bool {
+	return g.signal(0) == nil
+}
+
+// Kill sends SIGKILL to every process in the group.
+func (g processGroup) Kill() error {
+	return g.signal(syscall.SIGKILL)
+}
+
+// finished describes how a command ended from its process state.
+func finished(err error, state *os.ProcessState, duration time.Duration) types.RuneCommandFinished {
+	msg := types.RuneCommandFinished{Err: err, ExitCode: -1, Duration: duration}
+	if state == nil {
+		return msg
+	}
+	msg.ExitCode = state.ExitCode()
+	msg.UserTime = state.UserTime()
+	msg.SystemTime = state.SystemTime()
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		msg.Signal = status.Signal()
+	}
+	return msg
+}
+
+// Command returns the process ExecuteCommand would start for command,
+// without starting it.
+func (r *Runner) Command(command string, options ...ExecOption) *exec.Cmd {
+	opts := &execOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return r.command(command, opts)
+}
+
+func (r *Runner) command(command string, opts *execOptions) *exec.Cmd {
+	var argv []string
+	if opts.target != "" {
+		remote := command
+		if strings.TrimSpace(opts.interpreter) != "" {
+			remote = "exec " + ssh.QuoteArgs(CommandLine(opts.interpreter, command))
+		}
+		if len(opts.env) > 0 {
+			remote = "export " + ssh.QuoteArgs(opts.env) + " && " + remote
+		}
+		argv = ssh.CommandLine(opts.target, remote, opts.dir, opts.stdout == nil)
+	} else {
+		argv = CommandLine(r.Interpreter(opts.interpreter), command)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Stdout, cmd.Stderr = opts.stdout, opts.stderr
+	if opts.target == "" {
+		cmd.Env = append(cmd.Env, opts.env...)
+		cmd.Dir = opts.dir
+	}
+	return cmd
+}
+
+// ptyTerminal is the pseudo-terminal of a running command.
+type ptyTerminal struct {
+	ptmx *os.File
+
+	mu         sync.Mutex
+	cols, rows int
+}
+
+func (t *ptyTerminal) winsize() *pty.Winsize {
+	if t.cols <= 0 || t.rows <= 0 {
+		return nil
+	}
+	return &pty.Winsize{Cols: uint16(t.cols), Rows: uint16(t.rows)}
+}
+
+// Write sends input to the command.
+func (t *ptyTerminal) Write(p []byte) (int, error) {
+	return t.ptmx.Write(p)
+}
+
+// Resize changes the window size of the terminal, which notifies the
+// command with SIGWINCH. Resizing to the current size does nothing.
+func (t *ptyTerminal) Resize(cols, rows int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cols == t.cols && rows == t.rows {
+		return nil
+	}
+	t.cols, t.rows = cols, rows
+	if ws := t.winsize(); ws != nil {
+		return pty.Setsize(t.ptmx, ws)
+	}
+	return nil
 }
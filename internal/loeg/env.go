@@ -0,0 +1,155 @@
+package loeg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DotenvFile is the name of the file loaded from the spellbook directory.
+const DotenvFile = ".env"
+
+// Sources of environment variables, from lowest to highest precedence.
+const (
+	SourceProcess = "process"
+	SourceDotenv  = DotenvFile
+	SourceLoeg    = "loeg"
+)
+
+// Variable is an environment variable of a rune process.
+type Variable struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// Merge builds the environment of a rune process. The process environment
+// (in os.Environ form) is overridden by the .env file, which is overridden by
+// the loegs. The result is sorted by name.
+func Merge(environ []string, dotenv, loegs map[string]string) []Variable {
+	vars := make(map[string]Variable)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		vars[name] = Variable{Name: name, Value: value, Source: SourceProcess}
+	}
+	for name, value := range dotenv {
+		vars[name] = Variable{Name: name, Value: value, Source: SourceDotenv}
+	}
+	for name, value := range loegs {
+		vars[name] = Variable{Name: name, Value: value, Source: SourceLoeg}
+	}
+
+	merged := make([]Variable, 0, len(vars))
+	for _, v := range vars {
+		merged = append(merged, v)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return merged
+}
+
+// Environ returns vars as "NAME=value" pairs.
+func Environ(vars []Variable) []string {
+	env := make([]string, len(vars))
+	for i, v := range vars {
+		env[i] = v.Name + "=" + v.Value
+	}
+	return env
+}
+
+// LoadDotenv reads the .env file in dir. A missing file is not an error.
+func LoadDotenv(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, DotenvFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars, err := ParseDotenv(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name(), err)
+	}
+	return vars, nil
+}
+
+// ParseDotenv parses NAME=value lines. Blank lines and lines starting with #
+// are ignored, an "export " prefix is allowed, and values may be quoted:
+// double-quoted values understand \n, \t, \" and \\ escapes, single-quoted
+// values are taken literally. Unquoted values end at " #".
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func dotenvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
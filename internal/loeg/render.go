@@ -0,0 +1,48 @@
+package loeg
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// placeholder matches a {{.KEY}} placeholder, spaces allowed inside the
+// braces, along with a backslash escaping it.
+var placeholder = regexp.MustCompile(`\\?\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Render fills the {{.KEY}} placeholders of a rune command with the values
+// of loegs and parameters. A placeholder preceded by a backslash is kept
+// literally, without the backslash, for commands that take Go templates of
+// their own: docker ps --format '\{{.Names}}' runs docker ps --format
+// '{{.Names}}'. Other {{…}} text, such as {{range .items}}, is left as it is.
+//
+// Render fails when a placeholder names a key without a value. The command
+// is then returned with those placeholders unchanged, for previews.
+func Render(command string, values map[string]string) (string, error) {
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+	var missing []string
+	rendered := placeholder.ReplaceAllStringFunc(command, func(match string) string {
+		if literal, ok := strings.CutPrefix(match, `\`); ok {
+			return literal
+		}
+		key := placeholder.FindStringSubmatch(match)[1]
+		if value, ok := values[key]; ok {
+			return value
+		}
+		if !slices.Contains(missing, key) {
+			missing = append(missing, key)
+		}
+		return match
+	})
+	if len(missing) > 0 {
+		quoted := make([]string, len(missing))
+		for i, key := range missing {
+			quoted[i] = fmt.Sprintf("%q", key)
+		}
+		return rendered, fmt.Errorf("undefined loeg %s in %q (escape literal placeholders as \\{{.KEY}})", strings.Join(quoted, ", "), command)
+	}
+	return rendered, nil
+}
@@ -0,0 +1,29 @@
+package loeg
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	values := map[string]string{"HOST": "example.com", "PORT": "8080"}
+	tests := []struct {
+		command string
+		want    string
+		wantErr bool
+	}{
+		{"curl {{.HOST}}:{{.PORT}}", "curl example.com:8080", false},
+		{"curl {{ .HOST }}", "curl example.com", false},
+		{"echo no placeholders", "echo no placeholders", false},
+		{"echo {{.MISSING}} {{.HOST}}", "echo {{.MISSING}} example.com", true},
+		{"echo {{.MISSING}} {{.OTHER}} {{.MISSING}}", "echo {{.MISSING}} {{.OTHER}} {{.MISSING}}", true},
+		{`docker ps --format '\{{.Names}}'`, "docker ps --format '{{.Names}}'", false},
+		{`echo \{{.HOST}} {{.HOST}}`, "echo {{.HOST}} example.com", false},
+		{"kubectl get po -o go-template='{{range .items}}{{.metadata.name}}{{end}}'", "kubectl get po -o go-template='{{range .items}}{{.metadata.name}}{{end}}'", false},
+		{"echo {{.HOST}} {{.HOST.Name}}", "echo example.com {{.HOST.Name}}", false},
+		{"echo {{", "echo {{", false},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.command, values)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Render(%q) = %q, %v, want %q, error %v", tt.command, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// Format is how answers are printed: as a table by default, as JSON, or
+// through a Go template.
+type Format struct {
+	JSON bool
+	// Template is executed for each item of a list, or once for a single
+	// item, followed by a newline.
+	Template string
+}
+
+// Validate reports whether f asks for a single output format.
+func (f Format) Validate() error {
+	if f.JSON && f.Template != "" {
+		return errors.New("--json and --format can't be used together")
+	}
+	return nil
+}
+
+// funcs are the functions available to templates.
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func (f Format) template() (*template.Template, error) {
+	t, err := template.New("format").Funcs(funcs).Parse(f.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return t, nil
+}
+
+// Column is a column of a table of T.
+type Column[T any] struct {
+	Header string
+	Value  func(T) string
+}
+
+// PrintList prints items in format f, as a table with columns by default.
+func PrintList[T any](w io.Writer, f Format, items []T, columns []Column[T]) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	switch {
+	case f.JSON:
+		if items == nil {
+			items = []T{}
+		}
+		return printJSON(w, items)
+	case f.Template != "":
+		t, err := f.template()
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := t.Execute(w, item); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, item := range items {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			// Keep each item on a single row.
+			values[i] = strings.Join(strings.Fields(c.Value(item)), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// PrintItem prints item in format f, with detail by default.
+func PrintItem[T any](w io.Writer, f Format, item T, detail func(io.Writer, T) error) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	switch {
+	case f.JSON:
+		return printJSON(w, item)
+	case f.Template != "":
+		t, err := f.template()
+		if err != nil {
+			return err
+		}
+		if err := t.Execute(w, item); err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n")
+		return err
+	}
+	return detail(w, item)
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,104 @@
+// Package query answers questions about a spellbook and the execution
+// history without the TUI, for the command line.
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"catalyst/internal/db"
+	"catalyst/internal/spellbook"
+	"catalyst/internal/types"
+)
+
+// Loeg is a loeg of a spellbook.
+type Loeg struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Loegs returns the loegs of sb, sorted by name.
+func Loegs(sb *spellbook.Spellbook) []Loeg {
+	loegs := make([]Loeg, 0, len(sb.Loegs))
+	for name, value := range sb.Loegs {
+		loegs = append(loegs, Loeg{Name: name, Value: value})
+	}
+	slices.SortFunc(loegs, func(a, b Loeg) int { return strings.Compare(a.Name, b.Name) })
+	return loegs
+}
+
+// Execution is an execution recorded in the history.
+type Execution struct {
+	ID         int               `json:"id"`
+	Runes      []string          `json:"runes"`
+	Spellbook  string            `json:"spellbook"`
+	ExecutedAt time.Time         `json:"executed_at"`
+	Status     string            `json:"status"`
+	Trigger    string            `json:"trigger"`
+	Params     map[string]string `json:"params,omitempty"`
+	OutputDir  string            `json:"output_dir,omitempty"`
+}
+
+// History returns the executions recorded in the history, newest first. A
+// non-empty spellbookName keeps the executions of that spellbook only, and
+// a positive limit the latest ones.
+func History(database *db.Database, spellbookName string, limit int) ([]Execution, error) {
+	entries, err := database.GetHistory()
+	if err != nil {
+		return nil, err
+	}
+	executions := make([]Execution, 0, len(entries))
+	for _, entry := range entries {
+		if spellbookName != "" && entry.SpellbookID != spellbookName {
+			continue
+		}
+		if limit > 0 && len(executions) == limit {
+			break
+		}
+		trigger := entry.Trigger
+		if trigger == "" {
+			trigger = db.TriggerManual
+		}
+		executions = append(executions, Execution{
+			ID:         entry.ID,
+			Runes:      strings.Split(entry.RuneID, ","),
+			Spellbook:  entry.SpellbookID,
+			ExecutedAt: entry.ExecutedAt,
+			Status:     entry.Status,
+			Trigger:    trigger,
+			Params:     entry.Params,
+			OutputDir:  entry.OutputDir,
+		})
+	}
+	return executions, nil
+}
+
+// RuneDetail is what the rune detail pane shows about a rune.
+type RuneDetail struct {
+	types.Rune
+	// Plan lists the runes that run when the rune runs, in order, ending
+	// with the rune itself.
+	Plan []string `json:"plan"`
+	// Rendered holds the commands as they will run, see
+	// spellbook.Spellbook.Preview.
+	Rendered []string `json:"rendered"`
+}
+
+// Show returns the detail of the rune of sb named name.
+func Show(sb *spellbook.Spellbook, name string) (RuneDetail, error) {
+	r, ok := sb.Rune(name)
+	if !ok {
+		return RuneDetail{}, fmt.Errorf("no rune named %q in spellbook %s", name, sb.Name)
+	}
+	plan, err := sb.Plan([]types.Rune{r})
+	if err != nil {
+		return RuneDetail{}, err
+	}
+	detail := RuneDetail{Rune: r, Rendered: sb.Preview(r)}
+	for _, p := range plan {
+		detail.Plan = append(detail.Plan, p.Name)
+	}
+	return detail, nil
+}
@@ -0,0 +1,118 @@
+// Package runlog stores the output of rune executions on disk so they can be
+// looked at after the executing view is gone.
+//
+// Each execution gets a directory holding the raw terminal output of every
+// rune, the structured log of the execution and a manifest tying them
+// together.
+package runlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	manifestFile = "run.json"
+	logFile      = "catalyst.log"
+)
+
+// Rune is a rune of a stored execution.
+type Rune struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Output is the file holding the raw terminal output, relative to the
+	// run directory.
+	Output string `json:"output"`
+}
+
+// Run is the directory of one execution.
+type Run struct {
+	Dir string
+}
+
+// Create makes the directory for the execution recorded as history entry id
+// under dataDir.
+func Create(dataDir string, id int) (*Run, error) {
+	dir := filepath.Join(dataDir, "runs", strconv.Itoa(id))
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return &Run{Dir: dir}, nil
+}
+
+// OutputFile returns the name of the output file of the i-th rune.
+func OutputFile(i int) string {
+	return fmt.Sprintf("%02d.out", i+1)
+}
+
+// CreateOutput creates the output file of the i-th rune.
+func (r *Run) CreateOutput(i int) (*os.File, error) {
+	return os.Create(filepath.Join(r.Dir, OutputFile(i)))
+}
+
+// WriteOutput writes the whole output of the i-th rune.
+func (r *Run) WriteOutput(i int, output []byte) error {
+	return os.WriteFile(filepath.Join(r.Dir, OutputFile(i)), output, 0640)
+}
+
+// WriteLog writes the structured log of the execution.
+func (r *Run) WriteLog(content string) error {
+	return os.WriteFile(filepath.Join(r.Dir, logFile), []byte(content), 0640)
+}
+
+// WriteManifest records the runes of the execution and how they ended.
+func (r *Run) WriteManifest(runes []Rune) error {
+	data, err := json.MarshalIndent(runes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, manifestFile), data, 0640)
+}
+
+// Record is a stored execution read back from disk.
+type Record struct {
+	Runes []Rune
+	// Outputs holds the raw output of each rune, in the order of Runes.
+	Outputs [][]byte
+	Log     string
+}
+
+// Load reads the execution stored in dir. names lists the runes of the
+// execution, in order; it is used when the manifest is missing because
+// Catalyst exited before the execution ended.
+func Load(dir string, names []string) (*Record, error) {
+	var runes []Rune
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &runes); err != nil {
+			return nil, fmt.Errorf("invalid run manifest: %w", err)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		for i, name := range names {
+			runes = append(runes, Rune{Name: name, Status: "unknown", Output: OutputFile(i)})
+		}
+	default:
+		return nil, err
+	}
+
+	record := &Record{Runes: runes, Outputs: make([][]byte, len(runes))}
+	for i, r := range runes {
+		output, err := os.ReadFile(filepath.Join(dir, r.Output))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		record.Outputs[i] = output
+	}
+	log, err := os.ReadFile(filepath.Join(dir, logFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	record.Log = string(log)
+	return record, nil
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"*/15 * * * *", false},
+		{"0 9-17 * * 1-5", false},
+		{"0 0 1,15 * *", false},
+		{"0 0 * * 7", false},
+		{"0 0 29 2 *", false},
+		{"0 0 31 * *", false},
+		{"0 0 30 2 1", false}, // Mondays of February, the 30th never comes
+		{"@hourly", false},
+		{"@daily", false},
+		{"@weekly", false},
+		{"@monthly", false},
+		{"@yearly", false},
+		{"@every 1h30m", false},
+		{"  @every 5m  ", false},
+		{"", true},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"0 0 30 2 *", true},
+		{"0 0 31 4,6,9,11 *", true},
+		{"@every 30s", true},
+		{"@every soon", true},
+		{"@often", true},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec  string
+		after string
+		want  string
+	}{
+		{"* * * * *", "2025-03-10 12:00", "2025-03-10 12:01"},
+		{"*/15 * * * *", "2025-03-10 12:07", "2025-03-10 12:15"},
+		{"*/15 * * * *", "2025-03-10 12:45", "2025-03-10 13:00"},
+		{"30 9 * * *", "2025-03-10 09:30", "2025-03-11 09:30"},
+		{"0 0 * * *", "2025-12-31 23:59", "2026-01-01 00:00"},
+		{"0 9-17 * * 1-5", "2025-03-14 17:00", "2025-03-17 09:00"}, // Friday to Monday
+		{"0 0 * * 0", "2025-03-10 00:00", "2025-03-16 00:00"},
+		{"0 0 * * 7", "2025-03-10 00:00", "2025-03-16 00:00"},
+		{"0 0 1 * *", "2025-01-31 12:00", "2025-02-01 00:00"},
+		{"0 0 31 * *", "2025-04-01 00:00", "2025-05-31 00:00"},
+		{"0 0 29 2 *", "2025-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 29 2 *", "2096-03-01 00:00", "2104-02-29 00:00"},
+		{"0 0 13 * 5", "2025-03-10 00:00", "2025-03-13 00:00"}, // The 13th or a Friday
+		{"0 0 1 1 *", "2025-01-01 00:00", "2026-01-01 00:00"},
+		{"@every 90m", "2025-03-10 12:00", "2025-03-10 13:30"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(at(tt.after)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, tt.after, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	s := Every(time.Hour)
+	since := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		last time.Time
+		want time.Time
+	}{
+		{"never fired", time.Time{}, since.Add(time.Hour)},
+		{"fired before watching", since.Add(-30 * time.Minute), since.Add(time.Hour)},
+		{"fired while watching", since.Add(20 * time.Minute), since.Add(80 * time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := NextRun(s, tt.last, since); !got.Equal(tt.want) {
+			t.Errorf("%s: NextRun = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package spellbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"catalyst/internal/ssh"
+	"catalyst/internal/types"
+)
+
+// commandSeparator joins the commands of a rune in the -cmds flag of
+// RuneCraft's create-rune and update-rune.
+const commandSeparator = ";"
+
+// CreateRune adds r to the spellbook of the project at path. RuneCraft's
+// create-rune only takes a name, a description and commands without a
+// failure policy of their own; other settings are rejected.
+func CreateRune(client *ssh.Client, path string, r types.Rune) error {
+	if err := requireRune(r); err != nil {
+		return err
+	}
+	if err := flagChanges(types.Rune{}, r); err != nil {
+		return err
+	}
+	cmds := strings.Join(r.Lines(), commandSeparator)
+	_, err := client.Command(ssh.QuoteArgs([]string{"create-rune", path, "-name", r.Name, "-desc", r.Description, "-cmds", cmds}))
+	return err
+}
+
+// UpdateRune turns the rune old of the spellbook of the project at path into
+// r, sending only what changed. RuneCraft's update-rune only changes the
+// name, the description and the commands, which lose their failure policies;
+// other changes are rejected.
+func UpdateRune(client *ssh.Client, path string, old, r types.Rune) error {
+	if err := requireRune(r); err != nil {
+		return err
+	}
+	if err := flagChanges(old, r); err != nil {
+		return err
+	}
+	args := []string{"update-rune", path, old.Name}
+	if r.Name != old.Name {
+		args = append(args, "-name", r.Name)
+	}
+	if r.Description != old.Description {
+		args = append(args, "-desc", r.Description)
+	}
+	if !slices.Equal(r.Lines(), old.Lines()) {
+		args = append(args, "-cmds", strings.Join(r.Lines(), commandSeparator))
+	}
+	_, err := client.Command(ssh.QuoteArgs(args))
+	return err
+}
+
+// requireRune checks that r has what every rune needs.
+func requireRune(r types.Rune) error {
+	if r.Name == "" || r.Description == "" || len(r.Commands) == 0 ||
+		slices.ContainsFunc(r.Commands, func(c types.Command) bool { return c.Run == "" }) {
+		return errors.New("name, description, and at least one command are required")
+	}
+	return nil
+}
+
+// flagChanges checks that turning old into r only takes the -name, -desc
+// and -cmds flags of RuneCraft.
+func flagChanges(old, r types.Rune) error {
+	if !slices.Equal(r.Lines(), old.Lines()) {
+		for _, c := range r.Commands {
+			if strings.Contains(c.Run, commandSeparator) {
+				return fmt.Errorf("command %q contains %q, which separates the commands sent to RuneCraft; use a newline or && instead", c.Run, commandSeparator)
+			}
+			if c.Policy != nil {
+				return fmt.Errorf("RuneCraft can't store the failure policy of command %q; edit the spellbook on the RuneCraft host", c.Run)
+			}
+		}
+		old.Commands, r.Commands = nil, nil
+	}
+	old.Name, old.Description = "", ""
+	r.Name, r.Description = "", ""
+	before, err := json.Marshal(old)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if string(before) != string(after) {
+		return errors.New("RuneCraft only changes the name, description and commands of a rune; edit its other settings in the spellbook on the RuneCraft host")
+	}
+	return nil
+}
+
+// DeleteRune removes the rune named name from the spellbook of the project
+// at path.
+func DeleteRune(client *ssh.Client, path, name string) error {
+	_, err := client.Command(ssh.QuoteArgs([]string{"delete-rune", path, name}))
+	return err
+}
+
+// SetLoeg creates or updates a loeg of the spellbook of the project at path.
+func SetLoeg(client *ssh.Client, path, key, value string) error {
+	if key == "" || value == "" {
+		return errors.New("key and value are required")
+	}
+	if strings.Contains(key, "=") {
+		return fmt.Errorf("invalid loeg key %q: must not contain \"=\"", key)
+	}
+	_, err := client.Command(ssh.QuoteArgs([]string{"loeg", "set", path, key + "=" + value}))
+	return err
+}
+
+// RemoveLoeg removes a loeg from the spellbook of the project at path.
+func RemoveLoeg(client *ssh.Client, path, key string) error {
+	_, err := client.Command(ssh.QuoteArgs([]string{"loeg", "rm", path, key}))
+	return err
+}
@@ -0,0 +1,50 @@
+package spellbook
+
+import (
+	"testing"
+
+	"catalyst/internal/types"
+)
+
+func TestFlagChanges(t *testing.T) {
+	policy := &types.FailurePolicy{Retries: 2}
+	old := types.Rune{
+		Name:        "build",
+		Description: "Builds",
+		Commands:    []types.Command{{Run: "make"}, {Run: "make test", Policy: policy}},
+		Needs:       []string{"setup"},
+	}
+	with := func(change func(r *types.Rune)) types.Rune {
+		r := old
+		r.Commands = append([]types.Command(nil), old.Commands...)
+		change(&r)
+		return r
+	}
+	tests := []struct {
+		name    string
+		r       types.Rune
+		wantErr bool
+	}{
+		{"unchanged", old, false},
+		{"renamed", with(func(r *types.Rune) { r.Name, r.Description = "compile", "Compiles" }), false},
+		{"commands without policies", with(func(r *types.Rune) { r.Commands = types.Commands([]string{"go build", "go test"}) }), false},
+		{"commands keeping a policy", with(func(r *types.Rune) { r.Commands[0].Run = "go build" }), true},
+		{"command with a separator", with(func(r *types.Rune) { r.Commands = types.Commands([]string{"make; make test"}) }), true},
+		{"policy changed", with(func(r *types.Rune) { r.Commands[1].Policy = nil }), true},
+		{"needs changed", with(func(r *types.Rune) { r.Needs = nil }), true},
+	}
+	for _, tt := range tests {
+		if err := flagChanges(old, tt.r); (err != nil) != tt.wantErr {
+			t.Errorf("%s: flagChanges error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	created := types.Rune{Name: "n", Description: "d", Commands: types.Commands([]string{"true"})}
+	if err := flagChanges(types.Rune{}, created); err != nil {
+		t.Errorf("flagChanges for a new rune: %v", err)
+	}
+	created.Timeout = types.Duration(1)
+	if err := flagChanges(types.Rune{}, created); err == nil {
+		t.Error("flagChanges for a new rune with a timeout must fail")
+	}
+}
@@ -0,0 +1,111 @@
+// Package spellbook holds the runes and loegs of a project, as served by
+// RuneCraft, and works out how its runes run.
+package spellbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"catalyst/internal/loeg"
+	"catalyst/internal/ssh"
+	"catalyst/internal/types"
+)
+
+// Spellbook represents the entire content of a spellbook.
+type Spellbook struct {
+	Name  string            `json:"name"`
+	Runes []types.Rune      `json:"runes"`
+	Loegs map[string]string `json:"loegs"`
+	// Warnings can be added here in the future if the API supports it.
+}
+
+// ErrInvalid is returned when RuneCraft answers with something that isn't a
+// spellbook.
+var ErrInvalid = errors.New("invalid spellbook")
+
+// Fetch gets the spellbook of the project at path from RuneCraft. Any error
+// but ErrInvalid may mean the project has no spellbook yet.
+func Fetch(client *ssh.Client, path string) (*Spellbook, error) {
+	return command(client, ssh.QuoteArgs([]string{"get-spellbook-content", path}))
+}
+
+// Create creates the spellbook of the project at path on RuneCraft and
+// returns it.
+func Create(client *ssh.Client, path string) (*Spellbook, error) {
+	return command(client, ssh.QuoteArgs([]string{"create-spellbook", path}))
+}
+
+// command runs a RuneCraft command that answers with a spellbook.
+func command(client *ssh.Client, cmd string) (*Spellbook, error) {
+	jsonStr, err := client.Command(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var sb Spellbook
+	if err := json.Unmarshal([]byte(jsonStr), &sb); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return &sb, nil
+}
+
+// Rune returns the rune with the given name.
+func (sb *Spellbook) Rune(name string) (types.Rune, bool) {
+	if sb == nil {
+		return types.Rune{}, false
+	}
+	for _, r := range sb.Runes {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return types.Rune{}, false
+}
+
+// Preview renders the commands of r the way they will run, filling in the
+// loegs and the defaults of the rune's parameters. Parameters without a
+// default show as <NAME>.
+func (sb *Spellbook) Preview(r types.Rune) []string {
+	values := sb.previewValues(r.Parameters)
+	rendered := make([]string, len(r.Commands))
+	for i, cmd := range r.Commands {
+		// Undefined loegs stay as placeholders, the run reports them.
+		rendered[i], _ = loeg.Render(cmd.Run, values)
+	}
+	return rendered
+}
+
+// Script returns the commands r runs, sub-runes expanded, rendered like
+// Preview renders them.
+func (sb *Spellbook) Script(r types.Rune) ([]string, error) {
+	steps, err := sb.Expand(r)
+	if err != nil {
+		return nil, err
+	}
+	values := sb.previewValues(types.RuneParameters(sb.WithSubRunes([]types.Rune{r})))
+	script := make([]string, len(steps))
+	for i, step := range steps {
+		script[i], _ = loeg.Render(step.Command, values)
+	}
+	return script, nil
+}
+
+// previewValues returns the loegs and the defaults of params, or <NAME> for
+// parameters without a default.
+func (sb *Spellbook) previewValues(params []types.Parameter) map[string]string {
+	var values map[string]string
+	if sb != nil {
+		values = maps.Clone(sb.Loegs)
+	}
+	if values == nil {
+		values = make(map[string]string, len(params))
+	}
+	for _, p := range params {
+		values[p.Name] = p.Default
+		if p.Default == "" {
+			values[p.Name] = "<" + p.Name + ">"
+		}
+	}
+	return values
+}
@@ -0,0 +1,143 @@
+package spellbook
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"catalyst/internal/types"
+)
+
+// SubRunePrefix starts a command that runs another rune of the spellbook in
+// its place, e.g. "@rune build".
+const SubRunePrefix = "@rune "
+
+// MaxRuneDepth limits how deeply runes may invoke each other.
+const MaxRuneDepth = 8
+
+// Step is a command of a rune, once sub-runes are expanded.
+type Step struct {
+	Command string
+	Policy  types.FailurePolicy
+	Rune    types.Rune // Rune the command belongs to
+	Path    []string   // Runes leading to the command, outermost first
+}
+
+// Origin describes the rune a step comes from, e.g. "deploy › build".
+func (s Step) Origin() string {
+	return strings.Join(s.Path, " › ")
+}
+
+// SubRuneName returns the rune invoked by command, if it is a sub-rune
+// invocation.
+func SubRuneName(command string) (string, bool) {
+	command = strings.TrimSpace(command)
+	if !strings.HasPrefix(command, SubRunePrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(command, SubRunePrefix)), true
+}
+
+// Expand returns the commands of r with its sub-rune invocations replaced by
+// the commands of those runes. Each command keeps the failure policy of the
+// rune it belongs to and runs as that rune says.
+func (sb *Spellbook) Expand(r types.Rune) ([]Step, error) {
+	return sb.expand(r, nil)
+}
+
+func (sb *Spellbook) expand(r types.Rune, path []string) ([]Step, error) {
+	if slices.Contains(path, r.Name) {
+		return nil, fmt.Errorf("rune cycle: %s", strings.Join(append(path, r.Name), " → "))
+	}
+	if len(path) >= MaxRuneDepth {
+		return nil, fmt.Errorf("runes nested more than %d deep: %s", MaxRuneDepth, strings.Join(path, " → "))
+	}
+	path = append(slices.Clip(path), r.Name)
+
+	var steps []Step
+	for _, command := range r.Commands {
+		name, ok := SubRuneName(command.Run)
+		if !ok {
+			steps = append(steps, Step{Command: command.Run, Policy: r.Policy(command), Rune: r, Path: path})
+			continue
+		}
+		sub, ok := sb.Rune(name)
+		if !ok {
+			return nil, fmt.Errorf("rune %q invokes unknown rune %q", r.Name, name)
+		}
+		subSteps, err := sb.expand(sub, path)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, subSteps...)
+	}
+	return steps, nil
+}
+
+// WithSubRunes returns runes followed by every rune they invoke, directly or
+// not, each once. Unknown runes are left out.
+func (sb *Spellbook) WithSubRunes(runes []types.Rune) []types.Rune {
+	all := slices.Clone(runes)
+	seen := make(map[string]bool)
+	for _, r := range runes {
+		seen[r.Name] = true
+	}
+	for i := 0; i < len(all); i++ {
+		for _, command := range all[i].Commands {
+			name, ok := SubRuneName(command.Run)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			if sub, ok := sb.Rune(name); ok {
+				all = append(all, sub)
+			}
+		}
+	}
+	return all
+}
+
+// Plan orders runes after the runes they need, directly or not, with
+// every rune appearing once. Runes that need nothing are returned as they
+// are.
+func (sb *Spellbook) Plan(runes []types.Rune) ([]types.Rune, error) {
+	if !slices.ContainsFunc(runes, func(r types.Rune) bool { return len(r.Needs) > 0 }) {
+		return runes, nil
+	}
+
+	const (
+		visiting = iota + 1
+		planned
+	)
+	var plan []types.Rune
+	state := make(map[string]int)
+	var visit func(r types.Rune, path []string) error
+	visit = func(r types.Rune, path []string) error {
+		switch state[r.Name] {
+		case planned:
+			return nil
+		case visiting:
+			return fmt.Errorf("rune dependency cycle: %s", strings.Join(append(path, r.Name), " → "))
+		}
+		state[r.Name] = visiting
+		path = append(slices.Clip(path), r.Name)
+		for _, name := range r.Needs {
+			need, ok := sb.Rune(name)
+			if !ok {
+				return fmt.Errorf("rune %q needs unknown rune %q", r.Name, name)
+			}
+			if err := visit(need, path); err != nil {
+				return err
+			}
+		}
+		state[r.Name] = planned
+		plan = append(plan, r)
+		return nil
+	}
+	for _, r := range runes {
+		if err := visit(r, nil); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 // Client handles SSH connections to the RuneCraft server.
@@ -41,3 +42,38 @@ func (c *Client) Command(command string) (string, error) {
 
 	return stdout.String(), nil
 }
+
+// CommandLine returns the argv that runs command through ssh on host. The
+// command is interpreted by the remote login shell, in dir when it isn't
+// empty. With tty, a terminal is requested so output streams as it is
+// written, typed keys reach the command and it is hung up when the
+// connection drops. Without, its output and errors stay apart.
+func CommandLine(host, command, dir string, tty bool) []string {
+	if dir != "" {
+		command = fmt.Sprintf("cd %s && %s", Quote(dir), command)
+	}
+	if !tty {
+		return []string{"ssh", "-T", "--", host, command}
+	}
+	return []string{"ssh", "-tt", "--", host, command}
+}
+
+// Quote quotes s for a POSIX shell. Words made only of characters that are
+// never special to the shell are returned as they are.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, safeChars) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const safeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+// QuoteArgs quotes each of args and joins them into a shell command.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
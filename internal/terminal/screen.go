@@ -0,0 +1,560 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxScrollback is the number of lines kept once they scroll off the screen.
+const maxScrollback = 5000
+
+// Screen is an in-memory terminal emulator. Bytes written to it are
+// interpreted like a terminal would: carriage returns, cursor movement,
+// erasing and colors all update a screen buffer, and lines that scroll off
+// the top are kept as scrollback.
+type Screen struct {
+	width  int
+	height int
+
+	main       [][]cell
+	alt        [][]cell
+	lines      [][]cell // Points at main or alt
+	altScreen  bool
+	scrollback [][]cell
+
+	cx, cy       int
+	wrapNext     bool
+	autoWrap     bool
+	scrollTop    int
+	scrollBottom int
+	pen          style
+	saved        cursor
+	savedMain    cursor
+	parser       *ansi.Parser
+}
+
+type cursor struct {
+	x, y int
+	pen  style
+}
+
+type cell struct {
+	content string
+	width   int // 0 marks the trailing half of a wide character
+	style   style
+}
+
+// New creates a screen of the given size.
+func New(width, height int) *Screen {
+	s := &Screen{
+		width:  max(1, width),
+		height: max(1, height),
+	}
+	s.reset()
+
+	s.parser = ansi.NewParser()
+	s.parser.SetHandler(ansi.Handler{
+		Print:     s.print,
+		Execute:   s.execute,
+		HandleCsi: s.handleCsi,
+		HandleEsc: s.handleEsc,
+	})
+	return s
+}
+
+// reset puts the screen back in its initial state, keeping the scrollback.
+func (s *Screen) reset() {
+	s.pen = style{}
+	s.main = s.blankLines(s.height)
+	s.alt = s.blankLines(s.height)
+	s.lines = s.main
+	s.altScreen = false
+	s.cx, s.cy = 0, 0
+	s.wrapNext = false
+	s.autoWrap = true
+	s.scrollTop, s.scrollBottom = 0, s.height-1
+	s.saved, s.savedMain = cursor{}, cursor{}
+}
+
+// Write feeds terminal output into the screen. Escape sequences may be split
+// across writes.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.parser.Parse(p)
+	return len(p), nil
+}
+
+// Size returns the width and height of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Cursor returns the cursor position, zero-based.
+func (s *Screen) Cursor() (x, y int) {
+	return s.cx, s.cy
+}
+
+// Resize changes the screen size. Lines pushed off the top when shrinking are
+// moved to the scrollback.
+func (s *Screen) Resize(width, height int) {
+	width, height = max(1, width), max(1, height)
+	if width == s.width && height == s.height {
+		return
+	}
+
+	// resize fits lines to the new size, dropping lines off the top so the
+	// cursor row *cy stays on the screen, and moves *cy up with them.
+	resize := func(lines [][]cell, cy *int, keepScrollback bool) [][]cell {
+		if overflow := *cy - (height - 1); overflow > 0 {
+			if keepScrollback {
+				s.pushScrollback(lines[:overflow]...)
+			}
+			lines = lines[overflow:]
+			*cy -= overflow
+		}
+		for len(lines) < height {
+			lines = append(lines, s.blankLine(width))
+		}
+		lines = lines[:height]
+		for i, line := range lines {
+			switch {
+			case len(line) > width:
+				lines[i] = line[:width]
+			case len(line) < width:
+				for len(line) < width {
+					line = append(line, blankCell(style{}))
+				}
+				lines[i] = line
+			}
+		}
+		return lines
+	}
+
+	if s.altScreen {
+		// The main screen's cursor is the one saved when entering the
+		// alternate screen.
+		s.main = resize(s.main, &s.savedMain.y, true)
+		s.alt = resize(s.alt, &s.cy, false)
+		s.lines = s.alt
+	} else {
+		s.main = resize(s.main, &s.cy, true)
+		s.lines = s.main
+	}
+
+	s.width, s.height = width, height
+	s.scrollTop, s.scrollBottom = 0, height-1
+	s.cx = min(s.cx, width-1)
+	s.cy = min(s.cy, height-1)
+	s.savedMain.x = min(s.savedMain.x, width-1)
+	s.wrapNext = false
+}
+
+// Render returns the scrollback and screen as styled text. Empty lines below
+// the cursor are left out so short output doesn't leave a blank tail.
+func (s *Screen) Render() string {
+	return s.render(true)
+}
+
+// String returns the scrollback and screen as plain text.
+func (s *Screen) String() string {
+	return s.render(false)
+}
+
+func (s *Screen) render(styled bool) string {
+	var lines [][]cell
+	if !s.altScreen {
+		lines = append(lines, s.scrollback...)
+	}
+
+	last := s.cy
+	for y := len(s.lines) - 1; y > last; y-- {
+		if !isBlank(s.lines[y]) {
+			last = y
+			break
+		}
+	}
+	lines = append(lines, s.lines[:last+1]...)
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		writeLine(&b, line, styled)
+	}
+	return b.String()
+}
+
+func writeLine(b *strings.Builder, line []cell, styled bool) {
+	end := len(line)
+	for end > 0 && line[end-1].content == " " && line[end-1].style.bg == "" && line[end-1].style.attrs == 0 {
+		end--
+	}
+
+	current := style{}
+	for _, c := range line[:end] {
+		if c.width == 0 {
+			continue
+		}
+		if styled && c.style != current {
+			b.WriteString(c.style.sequence())
+			current = c.style
+		}
+		b.WriteString(c.content)
+	}
+	if styled && current != (style{}) {
+		b.WriteString(ansi.ResetStyle)
+	}
+}
+
+func isBlank(line []cell) bool {
+	for _, c := range line {
+		if c.content != " " || c.style.bg != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func blankCell(st style) cell {
+	// Erased cells keep only the background, like xterm's
+	// background-color-erase.
+	return cell{content: " ", width: 1, style: style{bg: st.bg}}
+}
+
+func (s *Screen) blankLine(width int) []cell {
+	line := make([]cell, width)
+	for i := range line {
+		line[i] = blankCell(s.pen)
+	}
+	return line
+}
+
+func (s *Screen) blankLines(n int) [][]cell {
+	lines := make([][]cell, n)
+	for i := range lines {
+		lines[i] = s.blankLine(s.width)
+	}
+	return lines
+}
+
+func (s *Screen) pushScrollback(lines ...[]cell) {
+	s.scrollback = append(s.scrollback, lines...)
+	if over := len(s.scrollback) - maxScrollback; over > 0 {
+		s.scrollback = append([][]cell(nil), s.scrollback[over:]...)
+	}
+}
+
+func (s *Screen) print(r rune) {
+	w := ansi.StringWidth(string(r))
+	if w == 0 {
+		// Combining characters join the previous cell.
+		x := s.cx
+		if !s.wrapNext && x > 0 {
+			x--
+		}
+		for x > 0 && s.lines[s.cy][x].width == 0 {
+			x--
+		}
+		s.lines[s.cy][x].content += string(r)
+		return
+	}
+	if w > s.width {
+		return
+	}
+
+	if s.wrapNext || s.cx+w > s.width {
+		if !s.autoWrap {
+			s.cx = s.width - w
+		} else {
+			s.cx = 0
+			s.lineFeed()
+		}
+		s.wrapNext = false
+	}
+
+	line := s.lines[s.cy]
+	line[s.cx] = cell{content: string(r), width: w, style: s.pen}
+	for i := 1; i < w; i++ {
+		line[s.cx+i] = cell{width: 0, style: s.pen}
+	}
+
+	s.cx += w
+	if s.cx >= s.width {
+		s.cx = s.width - 1
+		s.wrapNext = true
+	}
+}
+
+func (s *Screen) execute(b byte) {
+	switch b {
+	case ansi.CR:
+		s.cx = 0
+		s.wrapNext = false
+	case ansi.LF, ansi.VT, ansi.FF:
+		s.lineFeed()
+	case ansi.BS:
+		if s.cx > 0 {
+			s.cx--
+		}
+		s.wrapNext = false
+	case ansi.HT:
+		s.cx = min(s.width-1, (s.cx/8+1)*8)
+	}
+}
+
+func (s *Screen) lineFeed() {
+	s.wrapNext = false
+	if s.cy == s.scrollBottom {
+		s.scrollUp(1)
+	} else if s.cy < s.height-1 {
+		s.cy++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	s.wrapNext = false
+	if s.cy == s.scrollTop {
+		s.scrollDown(1)
+	} else if s.cy > 0 {
+		s.cy--
+	}
+}
+
+// scrollUp moves the lines of the scroll region up by n, adding blank lines
+// at the bottom. Lines leaving the top of the main screen become scrollback.
+func (s *Screen) scrollUp(n int) {
+	top, bottom := s.scrollTop, s.scrollBottom
+	n = min(n, bottom-top+1)
+	if top == 0 && !s.altScreen {
+		for _, line := range s.lines[:n] {
+			s.pushScrollback(append([]cell(nil), line...))
+		}
+	}
+	copy(s.lines[top:bottom+1], s.lines[top+n:bottom+1])
+	for y := bottom - n + 1; y <= bottom; y++ {
+		s.lines[y] = s.blankLine(s.width)
+	}
+}
+
+// scrollDown moves the lines of the scroll region down by n, adding blank
+// lines at the top.
+func (s *Screen) scrollDown(n int) {
+	top, bottom := s.scrollTop, s.scrollBottom
+	n = min(n, bottom-top+1)
+	copy(s.lines[top+n:bottom+1], s.lines[top:bottom+1-n])
+	for y := top; y < top+n; y++ {
+		s.lines[y] = s.blankLine(s.width)
+	}
+}
+
+func (s *Screen) moveTo(x, y int) {
+	s.cx = max(0, min(s.width-1, x))
+	s.cy = max(0, min(s.height-1, y))
+	s.wrapNext = false
+}
+
+func (s *Screen) eraseCells(y, from, to int) {
+	line := s.lines[y]
+	for x := max(0, from); x < min(len(line), to); x++ {
+		line[x] = blankCell(s.pen)
+	}
+}
+
+func (s *Screen) setAltScreen(on, saveCursor bool) {
+	if on == s.altScreen {
+		return
+	}
+	if on {
+		if saveCursor {
+			s.savedMain = cursor{x: s.cx, y: s.cy, pen: s.pen}
+		}
+		s.alt = s.blankLines(s.height)
+		s.lines = s.alt
+	} else {
+		s.lines = s.main
+		if saveCursor {
+			s.moveTo(s.savedMain.x, s.savedMain.y)
+			s.pen = s.savedMain.pen
+		}
+	}
+	s.altScreen = on
+	s.scrollTop, s.scrollBottom = 0, s.height-1
+	s.wrapNext = false
+}
+
+func (s *Screen) handleEsc(cmd ansi.Cmd) {
+	if cmd.Intermediate() != 0 {
+		// Character set designations and the like don't affect the buffer.
+		return
+	}
+	switch cmd.Final() {
+	case '7':
+		s.saved = cursor{x: s.cx, y: s.cy, pen: s.pen}
+	case '8':
+		s.moveTo(s.saved.x, s.saved.y)
+		s.pen = s.saved.pen
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cx = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *Screen) handleCsi(cmd ansi.Cmd, params ansi.Params) {
+	param := func(i, def int) int {
+		n, _, _ := params.Param(i, def)
+		if n == 0 && def > 0 {
+			return def
+		}
+		return n
+	}
+
+	if cmd.Intermediate() != 0 {
+		return
+	}
+	switch cmd.Prefix() {
+	case '?':
+		s.handlePrivateMode(cmd.Final(), params)
+		return
+	case 0:
+	default:
+		return
+	}
+
+	switch cmd.Final() {
+	case 'A':
+		s.moveTo(s.cx, max(s.cy-param(0, 1), s.scrollTopFor(s.cy)))
+	case 'B', 'e':
+		s.moveTo(s.cx, min(s.cy+param(0, 1), s.scrollBottomFor(s.cy)))
+	case 'C', 'a':
+		s.moveTo(s.cx+param(0, 1), s.cy)
+	case 'D':
+		s.moveTo(s.cx-param(0, 1), s.cy)
+	case 'E':
+		s.moveTo(0, s.cy+param(0, 1))
+	case 'F':
+		s.moveTo(0, s.cy-param(0, 1))
+	case 'G', '`':
+		s.moveTo(param(0, 1)-1, s.cy)
+	case 'd':
+		s.moveTo(s.cx, param(0, 1)-1)
+	case 'H', 'f':
+		s.moveTo(param(1, 1)-1, param(0, 1)-1)
+	case 'J':
+		switch param(0, 0) {
+		case 0:
+			s.eraseCells(s.cy, s.cx, s.width)
+			for y := s.cy + 1; y < s.height; y++ {
+				s.lines[y] = s.blankLine(s.width)
+			}
+		case 1:
+			s.eraseCells(s.cy, 0, s.cx+1)
+			for y := 0; y < s.cy; y++ {
+				s.lines[y] = s.blankLine(s.width)
+			}
+		case 2:
+			for y := range s.lines {
+				s.lines[y] = s.blankLine(s.width)
+			}
+		case 3:
+			s.scrollback = nil
+		}
+	case 'K':
+		switch param(0, 0) {
+		case 0:
+			s.eraseCells(s.cy, s.cx, s.width)
+		case 1:
+			s.eraseCells(s.cy, 0, s.cx+1)
+		case 2:
+			s.eraseCells(s.cy, 0, s.width)
+		}
+		s.wrapNext = false
+	case 'X':
+		s.eraseCells(s.cy, s.cx, s.cx+param(0, 1))
+	case '@':
+		n := min(param(0, 1), s.width-s.cx)
+		line := s.lines[s.cy]
+		copy(line[s.cx+n:], line[s.cx:s.width-n])
+		s.eraseCells(s.cy, s.cx, s.cx+n)
+	case 'P':
+		n := min(param(0, 1), s.width-s.cx)
+		line := s.lines[s.cy]
+		copy(line[s.cx:], line[s.cx+n:])
+		s.eraseCells(s.cy, s.width-n, s.width)
+	case 'L', 'M':
+		if s.cy < s.scrollTop || s.cy > s.scrollBottom {
+			return
+		}
+		top := s.scrollTop
+		s.scrollTop = s.cy
+		if cmd.Final() == 'L' {
+			s.scrollDown(param(0, 1))
+		} else {
+			// Deleted lines are not scrollback, so keep them off the top.
+			n := min(param(0, 1), s.scrollBottom-s.cy+1)
+			copy(s.lines[s.cy:s.scrollBottom+1], s.lines[s.cy+n:s.scrollBottom+1])
+			for y := s.scrollBottom - n + 1; y <= s.scrollBottom; y++ {
+				s.lines[y] = s.blankLine(s.width)
+			}
+		}
+		s.scrollTop = top
+		s.cx = 0
+	case 'S':
+		s.scrollUp(param(0, 1))
+	case 'T':
+		s.scrollDown(param(0, 1))
+	case 'r':
+		top, bottom := param(0, 1)-1, param(1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.scrollTop, s.scrollBottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saved = cursor{x: s.cx, y: s.cy, pen: s.pen}
+	case 'u':
+		s.moveTo(s.saved.x, s.saved.y)
+		s.pen = s.saved.pen
+	case 'm':
+		s.pen = s.pen.apply(params)
+	}
+}
+
+// scrollTopFor returns the upper limit for vertical cursor movement from row
+// y: the scroll region's top when y is inside it, the screen's top otherwise.
+func (s *Screen) scrollTopFor(y int) int {
+	if y >= s.scrollTop {
+		return s.scrollTop
+	}
+	return 0
+}
+
+// scrollBottomFor is the lower counterpart of scrollTopFor.
+func (s *Screen) scrollBottomFor(y int) int {
+	if y <= s.scrollBottom {
+		return s.scrollBottom
+	}
+	return s.height - 1
+}
+
+func (s *Screen) handlePrivateMode(final byte, params ansi.Params) {
+	if final != 'h' && final != 'l' {
+		return
+	}
+	set := final == 'h'
+	params.ForEach(0, func(_, mode int, _ bool) {
+		switch mode {
+		case 7:
+			s.autoWrap = set
+		case 47, 1047:
+			s.setAltScreen(set, false)
+		case 1049:
+			s.setAltScreen(set, true)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		before        string
+		resizeW       int
+		resizeH       int
+		after         string
+		want          string
+		wantX, wantY  int
+	}{
+		{
+			name:  "shrink moves lines above the cursor to the scrollback",
+			width: 10, height: 5,
+			before:  "1\r\n2\r\n3\r\n4\r\n5",
+			resizeW: 10, resizeH: 3,
+			want:  "1\n2\n3\n4\n5",
+			wantX: 1, wantY: 2,
+		},
+		{
+			name:  "shrink keeps lines below the cursor off the scrollback",
+			width: 10, height: 5,
+			before:  "1\r\n2\x1b[H",
+			resizeW: 10, resizeH: 1,
+			after: "x",
+			want:  "x",
+			wantX: 1, wantY: 0,
+		},
+		{
+			name:  "narrower truncates lines and clamps the cursor",
+			width: 10, height: 2,
+			before:  "abcdefgh",
+			resizeW: 4, resizeH: 2,
+			want:  "abcd",
+			wantX: 3, wantY: 0,
+		},
+		{
+			name:  "grow pads the screen",
+			width: 4, height: 2,
+			before:  "ab\r\ncd",
+			resizeW: 8, resizeH: 4,
+			after: "efgh",
+			want:  "ab\ncdefgh",
+			wantX: 6, wantY: 1,
+		},
+		{
+			name:  "leaving the alternate screen after a shrink restores a clamped cursor",
+			width: 80, height: 40,
+			before:  strings.Repeat("\r\n", 39) + "main\x1b[?1049h",
+			resizeW: 80, resizeH: 10,
+			after: "\x1b[?1049lx",
+			want:  strings.Repeat("\n", 39) + "mainx",
+			wantX: 5, wantY: 9,
+		},
+		{
+			name:  "leaving the alternate screen after a narrowing clamps the column",
+			width: 20, height: 5,
+			before:  "0123456789abcdef\x1b[?1049h",
+			resizeW: 8, resizeH: 5,
+			after: "\x1b[?1049lX",
+			want:  "0123456X",
+			wantX: 7, wantY: 0,
+		},
+		{
+			name:  "main screen keeps the lines above its saved cursor",
+			width: 10, height: 5,
+			before:  "a\r\nb\r\nc\r\nd\x1b[?1049h\x1b[5;1Halt",
+			resizeW: 10, resizeH: 2,
+			after: "\x1b[?1049l!",
+			want:  "a\nb\nc\nd!",
+			wantX: 2, wantY: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.width, tt.height)
+			_, _ = s.Write([]byte(tt.before))
+			s.Resize(tt.resizeW, tt.resizeH)
+			_, _ = s.Write([]byte(tt.after))
+
+			if got := s.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if x, y := s.Cursor(); x != tt.wantX || y != tt.wantY {
+				t.Errorf("Cursor() = %d, %d, want %d, %d", x, y, tt.wantX, tt.wantY)
+			}
+			if w, h := s.Size(); w != tt.resizeW || h != tt.resizeH {
+				t.Errorf("Size() = %d, %d, want %d, %d", w, h, tt.resizeW, tt.resizeH)
+			}
+		})
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "alternate screen hides the main screen",
+			output: "main\x1b[?1049halt",
+			want:   "    alt",
+		},
+		{
+			name:   "leaving restores the main screen and cursor",
+			output: "main\x1b[?1049h\x1b[3;3Halt\x1b[?1049l!",
+			want:   "main!",
+		},
+		{
+			name:   "alternate screen starts blank",
+			output: "\x1b[?1049hfirst\x1b[?1049l\x1b[?1049h",
+			want:   "",
+		},
+		{
+			name:   "scrollback is hidden while on the alternate screen",
+			output: "1\r\n2\r\n3\r\n4\x1b[?1049h\x1b[Halt",
+			want:   "alt",
+		},
+		{
+			name:   "mode 47 keeps the cursor",
+			output: "ab\x1b[?47h\x1b[2;2Hx\x1b[?47ly",
+			want:   "ab\n  y",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(10, 3)
+			_, _ = s.Write([]byte(tt.output))
+			if got := s.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
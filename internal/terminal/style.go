@@ -0,0 +1,162 @@
+package terminal
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Text attributes kept per cell.
+const (
+	attrBold uint16 = 1 << iota
+	attrFaint
+	attrItalic
+	attrUnderline
+	attrBlink
+	attrReverse
+	attrConceal
+	attrStrikethrough
+)
+
+// attrParams maps each attribute to the SGR parameter that enables it.
+var attrParams = []struct {
+	attr  uint16
+	param string
+}{
+	{attrBold, "1"},
+	{attrFaint, "2"},
+	{attrItalic, "3"},
+	{attrUnderline, "4"},
+	{attrBlink, "5"},
+	{attrReverse, "7"},
+	{attrConceal, "8"},
+	{attrStrikethrough, "9"},
+}
+
+// style is the graphic rendition of a cell. Colors are kept as the SGR
+// parameters that select them (e.g. "31" or "38;5;208") so they render back
+// exactly as the program wrote them.
+type style struct {
+	attrs uint16
+	fg    string
+	bg    string
+}
+
+// sequence returns the SGR sequence that switches from any style to s.
+func (s style) sequence() string {
+	params := []string{"0"}
+	for _, a := range attrParams {
+		if s.attrs&a.attr != 0 {
+			params = append(params, a.param)
+		}
+	}
+	if s.fg != "" {
+		params = append(params, s.fg)
+	}
+	if s.bg != "" {
+		params = append(params, s.bg)
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// apply returns the style after an SGR sequence with params.
+func (s style) apply(params ansi.Params) style {
+	if len(params) == 0 {
+		return style{}
+	}
+
+	for i := 0; i < len(params); i++ {
+		p := params[i].Param(0)
+		switch {
+		case p == 0:
+			s = style{}
+		case p == 1:
+			s.attrs |= attrBold
+		case p == 2:
+			s.attrs |= attrFaint
+		case p == 3:
+			s.attrs |= attrItalic
+		case p == 4:
+			s.attrs |= attrUnderline
+			// "4:0" turns underlining off.
+			if params[i].HasMore() && i+1 < len(params) {
+				i++
+				if params[i].Param(1) == 0 {
+					s.attrs &^= attrUnderline
+				}
+			}
+		case p == 5 || p == 6:
+			s.attrs |= attrBlink
+		case p == 7:
+			s.attrs |= attrReverse
+		case p == 8:
+			s.attrs |= attrConceal
+		case p == 9:
+			s.attrs |= attrStrikethrough
+		case p == 21 || p == 22:
+			s.attrs &^= attrBold | attrFaint
+		case p == 23:
+			s.attrs &^= attrItalic
+		case p == 24:
+			s.attrs &^= attrUnderline
+		case p == 25:
+			s.attrs &^= attrBlink
+		case p == 27:
+			s.attrs &^= attrReverse
+		case p == 28:
+			s.attrs &^= attrConceal
+		case p == 29:
+			s.attrs &^= attrStrikethrough
+		case p >= 30 && p <= 37, p >= 90 && p <= 97:
+			s.fg = strconv.Itoa(p)
+		case p == 39:
+			s.fg = ""
+		case p >= 40 && p <= 47, p >= 100 && p <= 107:
+			s.bg = strconv.Itoa(p)
+		case p == 49:
+			s.bg = ""
+		case p == 38 || p == 48:
+			color, n := extendedColor(params[i:])
+			i += n
+			if p == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+	return s
+}
+
+// extendedColor reads a 256-color or true-color selection starting at the
+// 38/48 parameter. It returns the SGR parameters for the color and how many
+// parameters after the first one it consumed.
+func extendedColor(params ansi.Params) (string, int) {
+	kind := params[0].Param(0)
+	if len(params) < 2 {
+		return "", len(params) - 1
+	}
+	switch params[1].Param(0) {
+	case 5:
+		if len(params) < 3 {
+			return "", len(params) - 1
+		}
+		return strconv.Itoa(kind) + ";5;" + strconv.Itoa(params[2].Param(0)), 2
+	case 2:
+		// Colon-separated forms may carry a color space id before r:g:b.
+		rgb := params[2:]
+		if params[1].HasMore() && len(rgb) > 3 && rgb[2].HasMore() && rgb[3].HasMore() {
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return "", len(params) - 1
+		}
+		consumed := len(params) - len(rgb) + 2
+		return strconv.Itoa(kind) + ";2;" +
+			strconv.Itoa(rgb[0].Param(0)) + ";" +
+			strconv.Itoa(rgb[1].Param(0)) + ";" +
+			strconv.Itoa(rgb[2].Param(0)), consumed
+	}
+	return "", 1
+}
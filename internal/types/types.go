@@ -1,10 +1,265 @@
 package types
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
 // Rune represents a single, executable script or command collection.
 type Rune struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Commands    []string `json:"commands"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Commands run one after another. A command of the form "@rune NAME"
+	// runs the commands of another rune of the spellbook in its place, each
+	// with that rune's interpreter, target and failure policy.
+	Commands []Command `json:"commands"`
+	// Needs lists the runes that must have run successfully before this
+	// one. Running the rune runs them first, each once.
+	Needs []string `json:"needs,omitempty"`
+	// Interpreter overrides the configured shell for this rune, e.g.
+	// "bash -euo pipefail", "python3 -c" or "sh".
+	Interpreter string `json:"interpreter,omitempty"`
+	// Workdir is the directory the commands run in, either absolute or
+	// relative to the spellbook path. Empty means the spellbook path itself.
+	Workdir string `json:"workdir,omitempty"`
+	// Target is where the commands run: empty for this machine, "runecraft"
+	// for the RuneCraft host, a host named in the config, or any ssh
+	// destination such as "deploy@build.example.com".
+	Target string `json:"target,omitempty"`
+	// Timeout limits how long the whole rune may run, retries included.
+	Timeout Duration `json:"timeout,omitempty"`
+	// FailurePolicy applies to every command of the rune that has no policy
+	// of its own.
+	FailurePolicy
+	// Parameters are asked for before the rune runs and fill the
+	// {{.NAME}} placeholders of its commands, like loegs do.
+	Parameters []Parameter `json:"parameters,omitempty"`
+	// Schedule makes the rune run by itself, either on a cron expression
+	// such as "*/15 * * * *" or on an interval such as "@every 1h".
+	// Scheduled runs use the parameters' defaults.
+	Schedule string `json:"schedule,omitempty"`
+	// Watch lists glob patterns of files in the spellbook directory, such
+	// as "**/*.go". In watch mode, changing a matching file runs the rune
+	// again.
+	Watch []string `json:"watch,omitempty"`
+}
+
+// Parameter is a value a rune asks for each time it runs.
+type Parameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is the value the form starts with.
+	Default string `json:"default,omitempty"`
+	// Choices, when set, lists the only values the parameter accepts.
+	Choices []string `json:"choices,omitempty"`
+}
+
+// Validate reports whether value is acceptable for the parameter.
+func (p Parameter) Validate(value string) error {
+	if value == "" {
+		return fmt.Errorf("parameter %q needs a value", p.Name)
+	}
+	if len(p.Choices) > 0 && !slices.Contains(p.Choices, value) {
+		return fmt.Errorf("parameter %q must be one of %s", p.Name, strings.Join(p.Choices, ", "))
+	}
+	return nil
+}
+
+// ParameterValues returns the values of params taken from values, falling
+// back to their defaults, or an error for the first one without a valid
+// value.
+func ParameterValues(params []Parameter, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(params))
+	for _, p := range params {
+		value, ok := values[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		if err := p.Validate(value); err != nil {
+			return nil, err
+		}
+		resolved[p.Name] = value
+	}
+	return resolved, nil
+}
+
+// RuneParameters returns the parameters of runes. A parameter declared by
+// several runes is asked for once, as declared by the first of them.
+func RuneParameters(runes []Rune) []Parameter {
+	var params []Parameter
+	seen := make(map[string]bool)
+	for _, r := range runes {
+		for _, p := range r.Parameters {
+			if seen[p.Name] {
+				continue
+			}
+			seen[p.Name] = true
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// FailurePolicy describes what happens when a command fails.
+type FailurePolicy struct {
+	// AllowFailure continues with the next command after a failure.
+	AllowFailure bool `json:"allow_failure,omitempty"`
+	// Retries is how many more times a failing command is attempted.
+	Retries int `json:"retries,omitempty"`
+	// Backoff is the delay before the first retry, doubled for each
+	// following one, e.g. "2s".
+	Backoff Duration `json:"backoff,omitempty"`
+	// CommandTimeout limits how long a single attempt of the command may
+	// run. Zero uses the configured default.
+	CommandTimeout Duration `json:"command_timeout,omitempty"`
+}
+
+// Delay returns how long to wait before the given retry, counting from 1.
+func (p FailurePolicy) Delay(retry int) time.Duration {
+	if retry < 1 || p.Backoff <= 0 {
+		return 0
+	}
+	return time.Duration(p.Backoff) << min(retry-1, 16)
+}
+
+// Policy returns the failure policy of c, a command of r.
+func (r Rune) Policy(c Command) FailurePolicy {
+	if c.Policy != nil {
+		return *c.Policy
+	}
+	return r.FailurePolicy
+}
+
+// Lines returns the text of the commands of r.
+func (r Rune) Lines() []string {
+	lines := make([]string, len(r.Commands))
+	for i, c := range r.Commands {
+		lines[i] = c.Run
+	}
+	return lines
+}
+
+// Command is a command of a rune. It is written to JSON as a string, or,
+// when it has a failure policy of its own, as an object with the command in
+// "run" next to the policy fields:
+//
+//	{"run": "make test", "retries": 2}
+type Command struct {
+	Run string
+	// Policy replaces the rune's failure policy for this command. Nil keeps
+	// the rune's.
+	Policy *FailurePolicy
+}
+
+// Commands returns commands without a failure policy of their own running
+// each of lines.
+func Commands(lines []string) []Command {
+	commands := make([]Command, len(lines))
+	for i, line := range lines {
+		commands[i] = Command{Run: line}
+	}
+	return commands
+}
+
+// Equal reports whether c and o run the same command with the same policy.
+func (c Command) Equal(o Command) bool {
+	if c.Run != o.Run || (c.Policy == nil) != (o.Policy == nil) {
+		return false
+	}
+	return c.Policy == nil || *c.Policy == *o.Policy
+}
+
+// commandObject is the JSON form of a command with a policy of its own.
+type commandObject struct {
+	Run string `json:"run"`
+	FailurePolicy
+}
+
+func (c Command) MarshalJSON() ([]byte, error) {
+	if c.Policy == nil {
+		return json.Marshal(c.Run)
+	}
+	return json.Marshal(commandObject{Run: c.Run, FailurePolicy: *c.Policy})
+}
+
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var run string
+	if err := json.Unmarshal(data, &run); err == nil {
+		*c = Command{Run: run}
+		return nil
+	}
+	var obj commandObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("command must be a string or an object with \"run\": %w", err)
+	}
+	if obj.Run == "" {
+		return errors.New("command object without \"run\"")
+	}
+	*c = Command{Run: obj.Run, Policy: &obj.FailurePolicy}
+	return nil
+}
+
+// Duration is a time.Duration that is written to JSON as a string such as
+// "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"2s\": %w", err)
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Terminal is the pseudo-terminal a command runs in. Writes are delivered to
+// the command as input.
+type Terminal interface {
+	io.Writer
+	Resize(cols, rows int) error
+}
+
+// Process is the process group of a running command.
+type Process interface {
+	// Kill sends SIGKILL to every process in the group.
+	Kill() error
+}
+
+// RuneCommandStarted is sent once a command is running.
+type RuneCommandStarted struct {
+	Terminal Terminal
+	Process  Process
+}
+
+// RuneCommandSignaled is sent when a canceled command's process group is
+// sent a signal to stop it.
+type RuneCommandSignaled struct {
+	Signal os.Signal
+}
+
+// RuneCommandCursorQuery is sent when a command asks for the cursor position.
+// The answer must be written to the command's Terminal. Extended queries
+// (DECXCPR) expect the "CSI ? row ; col R" form.
+type RuneCommandCursorQuery struct {
+	Extended bool
 }
 
 // RuneCommandOutputMsg is sent for each line of output from a command.
@@ -15,5 +270,18 @@ type RuneCommandOutputMsg struct {
 // RuneCommandFinished is sent when a command has finished executing.
 type RuneCommandFinished struct {
 	Err error
+	// ExitCode is the command's exit status, or -1 if it didn't exit on its
+	// own (it was killed by a signal or never started).
+	ExitCode int
+	// Signal is the signal that terminated the command, if any.
+	Signal os.Signal
+	// TimedOut is set when the command was stopped because its deadline
+	// passed.
+	TimedOut bool
+	// Duration is the wall time from start to exit.
+	Duration time.Duration
+	// UserTime and SystemTime are the CPU time the command and its waited-for
+	// children spent in user and kernel mode.
+	UserTime   time.Duration
+	SystemTime time.Duration
 }
-
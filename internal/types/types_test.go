@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCommandJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Command
+	}{
+		{`"make test"`, Command{Run: "make test"}},
+		{`{"run":"make test","retries":2,"backoff":"1s"}`, Command{Run: "make test", Policy: &FailurePolicy{Retries: 2, Backoff: Duration(time.Second)}}},
+		{`{"run":"lint","allow_failure":true}`, Command{Run: "lint", Policy: &FailurePolicy{AllowFailure: true}}},
+		{`{"run":"plain"}`, Command{Run: "plain", Policy: &FailurePolicy{}}},
+	}
+	for _, tt := range tests {
+		var got Command
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.data, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+		}
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("Marshal(%+v): %v", got, err)
+			continue
+		}
+		if string(data) != tt.data {
+			t.Errorf("Marshal(%+v) = %s, want %s", got, data, tt.data)
+		}
+	}
+
+	for _, data := range []string{`{"retries":2}`, `3`, `["a"]`} {
+		var c Command
+		if err := json.Unmarshal([]byte(data), &c); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want an error", data, c)
+		}
+	}
+}
+
+func TestRunePolicy(t *testing.T) {
+	var r Rune
+	if err := json.Unmarshal([]byte(`{
+		"name": "ci",
+		"retries": 1,
+		"commands": ["build", {"run": "test", "allow_failure": true}, "deploy"]
+	}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	// The policy stays with its command whatever the order.
+	r.Commands = []Command{r.Commands[2], r.Commands[1], r.Commands[0]}
+	want := []FailurePolicy{{Retries: 1}, {AllowFailure: true}, {Retries: 1}}
+	for i, c := range r.Commands {
+		if got := r.Policy(c); got != want[i] {
+			t.Errorf("Policy(%q) = %+v, want %+v", c.Run, got, want[i])
+		}
+	}
+}
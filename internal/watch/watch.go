@@ -0,0 +1,183 @@
+// Package watch tells when files matching glob patterns change, by comparing
+// snapshots of their size and modification time.
+//
+// Patterns are slash-separated and relative to the watched directory. They
+// use the syntax of path.Match for each path element, plus "**", which
+// matches any number of directories: "**/*.go" matches every Go file.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is the state of the files matching some patterns, by path
+// relative to the watched directory.
+type Snapshot map[string]fileState
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// skipDirs are not looked into unless a pattern starts in or inside them,
+// as in "vendor/foo/*.go": version control data, dependencies, caches and
+// build output, which are large and rarely edited.
+var skipDirs = []string{
+	".git", ".hg", ".svn",
+	"node_modules", "vendor", ".venv", "__pycache__", ".cache",
+	"build", "dist", "target",
+}
+
+// Scan takes a snapshot of the files under root matching any of patterns.
+func Scan(root string, patterns []string) (Snapshot, error) {
+	for _, p := range patterns {
+		if err := Validate(p); err != nil {
+			return nil, err
+		}
+	}
+	snapshot := make(Snapshot)
+	starts := startDirs(patterns)
+	for _, dir := range baseDirs(starts) {
+		err := filepath.WalkDir(filepath.Join(root, filepath.FromSlash(dir)), func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				if skipped(rel, starts) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !slices.ContainsFunc(patterns, func(p string) bool { return Match(p, rel) }) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				// Removed while walking.
+				return nil
+			}
+			snapshot[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// skipped reports whether the directory rel is left out when looking for
+// files under the directories starts.
+func skipped(rel string, starts []string) bool {
+	if !slices.Contains(skipDirs, path.Base(rel)) {
+		return false
+	}
+	return !slices.ContainsFunc(starts, func(start string) bool {
+		return start == rel || strings.HasPrefix(start, rel+"/")
+	})
+}
+
+// Changed returns the files created, modified or removed between s and
+// next, sorted.
+func (s Snapshot) Changed(next Snapshot) []string {
+	var changed []string
+	for name, state := range next {
+		if old, ok := s[name]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = append(changed, name)
+		}
+	}
+	for name := range s {
+		if _, ok := next[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Validate reports whether pattern is a valid pattern.
+func Validate(pattern string) error {
+	if pattern == "" || path.IsAbs(pattern) || strings.HasPrefix(pattern, "../") {
+		return fmt.Errorf("invalid watch pattern %q: must be relative to the spellbook directory", pattern)
+	}
+	for _, elem := range strings.Split(pattern, "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return fmt.Errorf("invalid watch pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the slash-separated relative path name matches
+// pattern.
+func Match(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// startDirs returns the directory each of patterns starts in: its leading
+// elements without wildcards.
+func startDirs(patterns []string) []string {
+	dirs := make([]string, len(patterns))
+	for i, p := range patterns {
+		elems := strings.Split(p, "/")
+		n := 0
+		for n < len(elems)-1 && !strings.ContainsAny(elems[n], `*?[\`) {
+			n++
+		}
+		dirs[i] = path.Clean(strings.Join(elems[:n], "/"))
+	}
+	return dirs
+}
+
+// baseDirs returns the directories to walk to find the files under dirs,
+// dropping directories inside others.
+func baseDirs(dirs []string) []string {
+	dirs = slices.Sorted(slices.Values(dirs))
+	var base []string
+	for _, d := range dirs {
+		if len(base) > 0 {
+			last := base[len(base)-1]
+			if d == last || last == "." || strings.HasPrefix(d, last+"/") {
+				continue
+			}
+		}
+		base = append(base, d)
+	}
+	return base
+}
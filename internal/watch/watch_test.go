@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/watch/watch.go", true},
+		{"src/**", "src/a/b.js", true},
+		{"src/**/*.js", "src/a.js", true},
+		{"src/**/*.js", "lib/a.js", false},
+		{"docs/*.md", "docs/a/b.md", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		"internal/a.go",
+		"internal/a.txt",
+		"vendor/lib/lib.go",
+		"node_modules/pkg/index.js",
+		"src/node_modules/pkg/index.js",
+		"src/app.js",
+		"build/out.go",
+		".git/hooks/hook.go",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"**/*.go"}, []string{"internal/a.go", "main.go"}},
+		{[]string{"**/*.js"}, []string{"src/app.js"}},
+		{[]string{"**/*.go", "vendor/lib/*.go"}, []string{"internal/a.go", "main.go", "vendor/lib/lib.go"}},
+		{[]string{"build/**"}, []string{"build/out.go"}},
+		{[]string{"src/node_modules/**/*.js"}, []string{"src/node_modules/pkg/index.js"}},
+		{[]string{"missing/*.go"}, nil},
+	}
+	for _, tt := range tests {
+		snapshot, err := Scan(root, tt.patterns)
+		if err != nil {
+			t.Errorf("Scan(%q): %v", tt.patterns, err)
+			continue
+		}
+		var got []string
+		for name := range snapshot {
+			got = append(got, name)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Scan(%q) = %q, want %q", tt.patterns, got, tt.want)
+		}
+	}
+
+	if _, err := Scan(root, []string{"../*.go"}); err == nil {
+		t.Error("Scan with a pattern outside the directory must fail")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "a")
+	write("b.go", "b")
+	before, err := Scan(root, []string{"*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("a.go", "changed")
+	write("c.go", "c")
+	if err := os.Remove(filepath.Join(root, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	after, err := Scan(root, []string{"*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := before.Changed(after), []string{"a.go", "b.go", "c.go"}; !slices.Equal(got, want) {
+		t.Errorf("Changed = %q, want %q", got, want)
+	}
+	if got := after.Changed(after); len(got) != 0 {
+		t.Errorf("Changed with itself = %q, want none", got)
+	}
+}